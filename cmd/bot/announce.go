@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/FolcloreX/GopherGram/internal/processor"
+	"github.com/FolcloreX/GopherGram/pipeline"
+)
+
+func cmdAnnounce(ctx context.Context, args []string) error {
+	fs := newFlagSet("announce", "<pasta do curso> [capa.jpg]",
+		"Gera o link de convite, atualiza a foto e a descrição do canal e posta o anúncio.\n"+
+			"Usa o canal salvo no estado (ou ORIGIN_CHAT_ID).")
+	cf := bindConfigFlags(fs)
+	cover := fs.String("cover", "", "imagem usada como foto do canal e no anúncio")
+	forceUnlock := fs.Bool("force-unlock", false, "remove os locks do curso e da sessão deixados por outro processo")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 1 || len(positional) > 2 {
+		fs.Usage()
+		return usageError("informe a pasta do curso")
+	}
+
+	coverPath := *cover
+	if len(positional) == 2 {
+		coverPath = positional[1]
+	}
+	if coverPath != "" {
+		if _, err := os.Stat(coverPath); os.IsNotExist(err) {
+			log.Printf("⚠️ Aviso: Capa informada não existe: %s (será enviado como texto)", coverPath)
+			coverPath = ""
+		}
+	}
+
+	cfg, err := cf.load(true)
+	if err != nil {
+		return err
+	}
+
+	rootDir, course, err := loadCourse(cfg, positional[0])
+	if err != nil {
+		return err
+	}
+	contentName := filepath.Base(rootDir)
+	if coverPath == "" {
+		coverPath = course.CoverPath
+	}
+
+	ws, err := openWorkspace(cfg, course, contentName, cfg.Phone, *forceUnlock)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	useSavedChat(cfg, ws.prog)
+	if cfg.ChatID == 0 {
+		return usageError("nenhum canal salvo para %s, informe --chat-id", contentName)
+	}
+
+	totalSizeBytes := processor.CalculateAssetsSize(course.Assets)
+	totalSizeBytes += processor.CalculateVideosSize(course.AllModules())
+
+	fmt.Println("⏱  Calculando duração dos vídeos...")
+	totalDurationSeconds := 0
+	for _, v := range course.Videos() {
+		seconds, err := processor.VideoDuration(v.FilePath)
+		if err != nil {
+			log.Printf("⚠️ Sem duração para %s: %v", v.FileName, err)
+			continue
+		}
+		totalDurationSeconds += seconds
+	}
+
+	bot := newTelegram(cfg)
+	return bot.Start(ctx, func(ctx context.Context) error {
+		if err := bot.CheckChatAccess(ctx); err != nil {
+			return fmt.Errorf("erro ao acessar chat origem: %w", err)
+		}
+		if err := bot.ResolvePostTarget(ctx); err != nil {
+			return fmt.Errorf("erro ao resolver grupo de divulgação: %w", err)
+		}
+
+		pipeline.Announce(ctx, bot, pipeline.Announcement{
+			Title:         course.Title,
+			Description:   course.Description,
+			Author:        course.Author,
+			Year:          course.Year,
+			CoverPath:     coverPath,
+			Logo:          cfg.Logo,
+			TotalSize:     totalSizeBytes,
+			TotalDuration: totalDurationSeconds,
+		})
+		return nil
+	})
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/FolcloreX/GopherGram/internal/processor"
+	"github.com/FolcloreX/GopherGram/internal/state"
+	"github.com/FolcloreX/GopherGram/internal/workdir"
+)
+
+// Names of the zip of the assets and its parts (Arquivos.part1.zip...), see SplitFileBinary
+var oldZipRe = regexp.MustCompile(`^Arquivos(\.part[0-9]+)?\.zip$`)
+
+// oldZips lists the zips of the assets created in the folder by older versions
+func oldZips(dir string) []string {
+	entries, _ := os.ReadDir(dir)
+	var zips []string
+	for _, e := range entries {
+		if !e.IsDir() && oldZipRe.MatchString(e.Name()) {
+			zips = append(zips, filepath.Join(dir, e.Name()))
+		}
+	}
+	return zips
+}
+
+func cmdClean(ctx context.Context, args []string) error {
+	fs := newFlagSet("clean", "<pasta do curso>",
+		"Remove as partes de vídeos, thumbnails e zips deixados por execuções que falharam.\n"+
+			"Os arquivos originais nunca são apagados.")
+	cf := bindConfigFlags(fs)
+	resetState := fs.Bool("state", false, "apaga também o estado do curso (o próximo upload começa do zero)")
+	dryRun := fs.Bool("dry-run", false, "só lista o que seria apagado")
+	forceUnlock := fs.Bool("force-unlock", false, "remove o lock do curso deixado por outro processo")
+	yes := fs.Bool("yes", false, "apaga sem perguntar")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return usageError("informe a pasta do curso")
+	}
+
+	cfg, err := cf.load(false)
+	if err != nil {
+		return err
+	}
+
+	rootDir, err := filepath.Abs(positional[0])
+	if err != nil {
+		return usageError("erro path: %v", err)
+	}
+	if info, err := os.Stat(rootDir); err != nil || !info.IsDir() {
+		return usageError("pasta inválida: %s", rootDir)
+	}
+	contentName := filepath.Base(rootDir)
+
+	// Never remove the parts of an upload that is still running
+	release, err := acquireLocks(contentName, "", *forceUnlock)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	leftovers, err := processor.FindLeftovers(rootDir)
+	if err != nil {
+		return fmt.Errorf("erro ao procurar sobras: %w", err)
+	}
+
+	// Older versions created the zip of the assets in the working directory.
+	// Only its exact names, never other zips of the user
+	leftovers = append(leftovers, oldZips(".")...)
+
+	// Folders of runs that crashed, with their parts and converted copies
+	stale, err := workdir.FindStale(cfg.WorkDir)
+	if err != nil {
+		return fmt.Errorf("erro ao procurar pastas de trabalho: %w", err)
+	}
+	leftovers = append(leftovers, stale...)
+
+	if len(leftovers) > 0 {
+		fmt.Printf("🧹 %d arquivo(s) para apagar:\n", len(leftovers))
+	}
+	for _, path := range leftovers {
+		fmt.Printf("   🗑  %s\n", path)
+	}
+
+	resetting := false
+	if *resetState {
+		exists, err := state.Exists(cfg.StateBackend, cfg.StateDB, contentName)
+		if err != nil {
+			return fmt.Errorf("erro no estado: %w", err)
+		}
+		if exists {
+			fmt.Printf("💾 O estado de %s também será apagado\n", contentName)
+			resetting = true
+		} else {
+			fmt.Println("📭 Nenhum estado salvo para este curso.")
+		}
+	}
+
+	if len(leftovers) == 0 && !resetting {
+		fmt.Println("🧹 Nada para apagar")
+		return nil
+	}
+	if *dryRun {
+		fmt.Println("🧹 Nada foi apagado (--dry-run)")
+		return nil
+	}
+	if !*yes && !confirm("Apagar?") {
+		fmt.Println("🧹 Nada foi apagado")
+		return nil
+	}
+
+	removed := 0
+	for _, path := range leftovers {
+		if err := os.RemoveAll(path); err != nil {
+			fmt.Printf("   ⚠️ Erro ao apagar %s: %v\n", path, err)
+			continue
+		}
+		removed++
+	}
+	fmt.Printf("🧹 %d arquivo(s) apagados\n", removed)
+
+	if !resetting {
+		return nil
+	}
+
+	prog, err := state.OpenStore(cfg.StateBackend, cfg.StateDB, contentName)
+	if err != nil {
+		return fmt.Errorf("erro no estado: %w", err)
+	}
+	defer prog.Close()
+
+	if err := prog.Reset(); err != nil {
+		return fmt.Errorf("erro ao apagar estado: %w", err)
+	}
+	fmt.Printf("💾 Estado apagado: %s\n", prog.Location())
+	return nil
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/FolcloreX/GopherGram/internal/config"
+	"github.com/FolcloreX/GopherGram/internal/domain"
+	"github.com/FolcloreX/GopherGram/internal/lock"
+	"github.com/FolcloreX/GopherGram/internal/scanner"
+	"github.com/FolcloreX/GopherGram/internal/state"
+	"github.com/FolcloreX/GopherGram/internal/telegram"
+)
+
+// Exit codes of the program
+const (
+	exitOK      = 0
+	exitFailure = 1 // Unexpected errors
+	exitUsage   = 2 // Wrong command, flags or arguments
+	exitConfig  = 3 // Invalid .env or config flags
+	exitAuth    = 4 // Telegram login failed
+	exitPartial = 5 // The upload stopped in the middle, run resume to continue
+)
+
+// exitError carries the exit code of the failure
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+func withCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+func usageError(format string, args ...any) error {
+	return withCode(exitUsage, fmt.Errorf(format, args...))
+}
+
+func exitCode(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	if errors.Is(err, telegram.ErrAuth) {
+		return exitAuth
+	}
+	return exitFailure
+}
+
+// newTelegram creates the Telegram client of the commands, the tests swap it for a fake
+var newTelegram = func(cfg *config.Config) telegram.Service {
+	return telegram.NewClient(cfg)
+}
+
+// newFlagSet creates the flags of a command with its help text
+func newFlagSet(name, args, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Uso: gophergram %s [flags] %s\n\n%s\n\nFlags:\n", name, args, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses the flags allowing them before and after the positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, withCode(exitUsage, err)
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// configFlags binds a flag to every config.Config field.
+// Only the flags that were passed override the values of the .env.
+type configFlags struct {
+	envFile   string
+	overrides []func(cfg *config.Config)
+}
+
+func bindConfigFlags(fs *flag.FlagSet) *configFlags {
+	cf := &configFlags{}
+
+	fs.StringVar(&cf.envFile, "env", ".env", "arquivo de configuração")
+
+	str := func(name, usage string, set func(cfg *config.Config, v string)) {
+		fs.Func(name, usage, func(v string) error {
+			cf.overrides = append(cf.overrides, func(cfg *config.Config) { set(cfg, v) })
+			return nil
+		})
+	}
+	integer := func(name, usage string, set func(cfg *config.Config, v int64)) {
+		fs.Func(name, usage, func(v string) error {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return fmt.Errorf("número inválido: %s", v)
+			}
+			cf.overrides = append(cf.overrides, func(cfg *config.Config) { set(cfg, n) })
+			return nil
+		})
+	}
+
+	integer("api-id", "API_ID do my.telegram.org", func(c *config.Config, v int64) { c.APIID = int(v) })
+	str("api-hash", "API_HASH do my.telegram.org", func(c *config.Config, v string) { c.APIHash = v })
+	str("phone", "telefone da conta (PHONE_NUMBER)", func(c *config.Config, v string) { c.Phone = v })
+	str("password", "senha 2FA (PASSWORD)", func(c *config.Config, v string) { c.Password = v })
+	integer("chat-id", "canal/grupo de destino (ORIGIN_CHAT_ID)", func(c *config.Config, v int64) { c.ChatID = v })
+	str("logo", "assinatura das mensagens (LOGO)", func(c *config.Config, v string) { c.Logo = v })
+	integer("post-group", "grupo de divulgação (POST_GROUP_ID)", func(c *config.Config, v int64) { c.PostGroupID = v })
+	integer("post-topic", "tópico do grupo de divulgação (POST_GROUP_TOPIC_ID)", func(c *config.Config, v int64) { c.PostGroupTopicID = int(v) })
+	integer("prepare-workers", "vídeos preparados ao mesmo tempo (PREPARE_WORKERS)", func(c *config.Config, v int64) { c.PrepareWorkers = int(v) })
+	integer("upload-workers", "arquivos enviados ao mesmo tempo (UPLOAD_WORKERS)", func(c *config.Config, v int64) { c.UploadWorkers = int(v) })
+	str("index-style", "estilo do menu: links ou hashtags (INDEX_STYLE)", func(c *config.Config, v string) { c.IndexStyle = v })
+	str("state-backend", "onde salvar o estado: json ou sqlite (STATE_BACKEND)", func(c *config.Config, v string) { c.StateBackend = v })
+	str("state-db", "banco do backend sqlite (STATE_DB)", func(c *config.Config, v string) { c.StateDB = v })
+	str("root-module", "nome do módulo dos vídeos da raiz do curso (ROOT_MODULE_NAME)", func(c *config.Config, v string) { c.RootModule = v })
+	str("subtitle-mode", "como enviar as legendas: reply, mux ou burn (SUBTITLE_MODE)", func(c *config.Config, v string) { c.SubtitleMode = v })
+	str("subtitle-lang", "idioma da legenda gravada no vídeo com burn, ex: pt-BR (SUBTITLE_LANG)", func(c *config.Config, v string) { c.SubtitleLang = v })
+	str("video-convert", "vídeos que o Telegram não toca sem baixar: none, remux ou transcode (VIDEO_CONVERT)", func(c *config.Config, v string) { c.VideoConvert = v })
+	str("size-limit", "tamanho máximo dos arquivos: compatible (2GB) ou premium (4GB, conta Premium) (SIZE_LIMIT)", func(c *config.Config, v string) { c.SizeLimit = v })
+	str("work-dir", "pasta dos arquivos gerados (zip, partes, thumbnails), padrão a temporária do sistema (WORK_DIR)", func(c *config.Config, v string) { c.WorkDir = v })
+	integer("module-depth", "níveis de subpastas que viram módulos, 0 = todos (MODULE_DEPTH)", func(c *config.Config, v int64) { c.ModuleDepth = int(v) })
+
+	// Repeatable, the flags replace the list of the .env
+	list := func(name, usage string, get func(cfg *config.Config) *[]string) {
+		given := false
+		fs.Func(name, usage, func(v string) error {
+			first := !given
+			given = true
+			cf.overrides = append(cf.overrides, func(cfg *config.Config) {
+				if first {
+					*get(cfg) = nil
+				}
+				*get(cfg) = append(*get(cfg), v)
+			})
+			return nil
+		})
+	}
+	list("include", "glob dos arquivos enviados, pode repetir (INCLUDE)", func(c *config.Config) *[]string { return &c.Include })
+	list("exclude", "glob dos arquivos e pastas ignorados, pode repetir (EXCLUDE)", func(c *config.Config) *[]string { return &c.Exclude })
+
+	return cf
+}
+
+// load reads the .env, applies the flags and validates the result.
+// Offline commands don't need the Telegram credentials.
+func (cf *configFlags) load(online bool) (*config.Config, error) {
+	cfg, err := config.LoadFile(cf.envFile)
+	if err != nil {
+		return nil, withCode(exitConfig, err)
+	}
+	for _, override := range cf.overrides {
+		override(cfg)
+	}
+
+	validate := cfg.ValidateOptions
+	if online {
+		validate = cfg.Validate
+	}
+	if err := validate(); err != nil {
+		return nil, withCode(exitConfig, err)
+	}
+	return cfg, nil
+}
+
+// loadCourse validates the folder and scans it with the include/exclude of the config
+func loadCourse(cfg *config.Config, folder string) (rootDir string, course *domain.Course, err error) {
+	return loadCourseTo(os.Stdout, cfg, folder)
+}
+
+// loadCourseTo is loadCourse writing the progress to out
+func loadCourseTo(out io.Writer, cfg *config.Config, folder string) (rootDir string, course *domain.Course, err error) {
+	rootDir, err = filepath.Abs(folder)
+	if err != nil {
+		return "", nil, usageError("erro path: %v", err)
+	}
+
+	info, err := os.Stat(rootDir)
+	if err != nil || !info.IsDir() {
+		return "", nil, usageError("pasta inválida: %s", rootDir)
+	}
+
+	fmt.Fprintln(out, "🔍 Escaneando arquivos...")
+	s := scanner.New(rootDir)
+	s.Include = cfg.Include
+	s.Exclude = cfg.Exclude
+	s.MaxDepth = cfg.ModuleDepth
+	s.RootModule = cfg.RootModule
+
+	course, err = s.Scan()
+	if errors.Is(err, scanner.ErrInvalidManifest) || errors.Is(err, scanner.ErrInvalidIgnore) {
+		return "", nil, withCode(exitConfig, err)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("erro no scanner: %w", err)
+	}
+	if len(course.Ignored) > 0 {
+		fmt.Fprintf(out, "🚫 %d arquivo(s) ignorado(s) (veja com plan)\n", len(course.Ignored))
+	}
+	// Unlike the ignore rules these weren't chosen by the user, so each one is shown
+	for _, ig := range course.Ignored {
+		if ig.Reason == scanner.ReasonLeftover {
+			rel, _ := filepath.Rel(rootDir, ig.Path)
+			fmt.Fprintf(out, "   ⚠️ %s: %s\n", rel, ig.Reason)
+		}
+	}
+	return rootDir, course, nil
+}
+
+// stdin is read by confirm, replaced in the tests
+var stdin io.Reader = os.Stdin
+
+// confirm asks a yes or no question, anything but "s" or "sim" is no
+func confirm(question string) bool {
+	fmt.Printf("%s [s/N] ", question)
+	var answer string
+	fmt.Fscanln(stdin, &answer)
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "s" || answer == "sim"
+}
+
+// acquireLocks takes the lock of the course and of the Telegram session.
+// Only one uploader per course and per Telegram session. An empty name or
+// phone skips the lock.
+func acquireLocks(contentName, phone string, force bool) (release func(), err error) {
+	if err := os.MkdirAll("session", 0700); err != nil {
+		return nil, fmt.Errorf("erro pasta session: %w", err)
+	}
+
+	var locks []*lock.Lock
+	release = func() {
+		for _, l := range locks {
+			l.Release()
+		}
+	}
+
+	if contentName != "" {
+		l, err := lock.Acquire(state.LockPath(contentName), "upload de "+contentName, force)
+		if err != nil {
+			return nil, fmt.Errorf("🔒 %w", err)
+		}
+		locks = append(locks, l)
+	}
+
+	if phone != "" {
+		l, err := lock.Acquire(telegram.SessionPath(phone)+".lock", "sessão de "+phone, force)
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("🔒 %w", err)
+		}
+		locks = append(locks, l)
+	}
+
+	return release, nil
+}
+
+// workspace is the locked state of a course
+type workspace struct {
+	prog    state.ProgressStore
+	release func()
+}
+
+// openWorkspace locks the course (and the session, if phone is set) and opens its state
+func openWorkspace(cfg *config.Config, course *domain.Course, contentName, phone string, force bool) (*workspace, error) {
+	release, err := acquireLocks(contentName, phone, force)
+	if err != nil {
+		return nil, err
+	}
+
+	// The course folder may have been renamed, look for the state by the content
+	if cfg.StateBackend == state.BackendJSON && course != nil {
+		relocateProgress(course, contentName)
+	}
+
+	prog, err := state.OpenStore(cfg.StateBackend, cfg.StateDB, contentName)
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("erro no estado: %w", err)
+	}
+	fmt.Printf("💾 Estado carregado: %s\n", prog.Location())
+
+	return &workspace{prog: prog, release: release}, nil
+}
+
+func (w *workspace) Close() {
+	w.prog.Close()
+	w.release()
+}
+
+// useSavedChat makes the client use the chat of the previous runs when none was configured
+func useSavedChat(cfg *config.Config, prog state.ProgressStore) {
+	if cfg.ChatID != 0 {
+		return
+	}
+	if saved := prog.GetChatID(); saved != 0 {
+		fmt.Printf("♻️  Usando o chat salvo no estado: %d\n", saved)
+		cfg.ChatID = saved
+	}
+}
+
+// relocateProgress copies the state of a course whose folder was renamed or moved,
+// found by the fingerprints of its videos. The old state is kept, and its lock is
+// held during the copy so an upload still running under the old name isn't read half way.
+func relocateProgress(course *domain.Course, contentName string) {
+	target := state.ProgressPath(contentName)
+	if _, err := os.Stat(target); err == nil {
+		return
+	}
+
+	found, err := findRenamedProgress(course)
+	if err != nil || found == "" {
+		return
+	}
+
+	release, err := acquireLocks(state.NameOf(found), "", false)
+	if err != nil {
+		log.Printf("⚠️ Estado encontrado pelo conteúdo em uso, não copiado: %v", err)
+		return
+	}
+	defer release()
+
+	fmt.Printf("🔁 Estado encontrado pelo conteúdo: %s -> %s\n", found, target)
+	if err := state.Copy(found, target); err != nil {
+		log.Printf("⚠️ Erro ao copiar estado: %v", err)
+	}
+}
+
+// findRenamedProgress looks for the json state that has most of the videos of the course
+func findRenamedProgress(course *domain.Course) (string, error) {
+	var fingerprints []string
+	for _, v := range course.Videos() {
+		fp, err := state.Fingerprint(v.FilePath)
+		if err != nil {
+			return "", nil
+		}
+		fingerprints = append(fingerprints, fp)
+	}
+	if len(fingerprints) == 0 {
+		return "", nil
+	}
+	return state.FindByFingerprints(fingerprints)
+}
+
+// courseName is the name of the course, the base name of its folder
+func courseName(folder string) (string, error) {
+	rootDir, err := filepath.Abs(folder)
+	if err != nil {
+		return "", usageError("erro path: %v", err)
+	}
+	return filepath.Base(rootDir), nil
+}
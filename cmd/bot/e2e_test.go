@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/FolcloreX/GopherGram/internal/config"
+	"github.com/FolcloreX/GopherGram/internal/scanner"
+	"github.com/FolcloreX/GopherGram/internal/state"
+	"github.com/FolcloreX/GopherGram/internal/telegram"
+	"github.com/FolcloreX/GopherGram/internal/telegram/fake"
+)
+
+// Credentials of the fake account, the .env is never read in the tests
+var creds = []string{"--api-id", "1", "--api-hash", "hash", "--phone", "5511999999999"}
+
+// setup runs the test in an empty working directory (session/ and the zip are
+// created there) with the commands talking to the returned fake server.
+func setup(t *testing.T) *fake.Server {
+	t.Chdir(t.TempDir())
+
+	for _, key := range []string{"API_ID", "API_HASH", "PHONE_NUMBER", "PASSWORD", "ORIGIN_CHAT_ID",
+		"POST_GROUP_ID", "POST_GROUP_TOPIC_ID", "INDEX_STYLE", "STATE_BACKEND", "STATE_DB"} {
+		t.Setenv(key, "")
+	}
+
+	srv := fake.NewServer()
+	previous := newTelegram
+	newTelegram = func(cfg *config.Config) telegram.Service { return srv.Client(cfg) }
+	t.Cleanup(func() { newTelegram = previous })
+
+	return srv
+}
+
+// writeCourse creates the files of a synthetic course, each one with a different content
+func writeCourse(t *testing.T, root string, files ...string) string {
+	t.Helper()
+	for _, name := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := strings.Repeat(name+"\n", 100)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func defaultCourse(t *testing.T) string {
+	return writeCourse(t, "Curso de Go",
+		"01 Intro/01 Instalação.mp4",
+		"01 Intro/02 Hello World.mp4",
+		"01 Intro/apostila.pdf",
+		"02 Sintaxe/01 Variáveis.mp4",
+	)
+}
+
+func run(t *testing.T, cmd func(context.Context, []string) error, args ...string) error {
+	t.Helper()
+	return cmd(context.Background(), append(append([]string{}, creds...), args...))
+}
+
+// onlyChat returns the channel created by the upload
+func onlyChat(t *testing.T, srv *fake.Server) *fake.Chat {
+	t.Helper()
+	chats := srv.Chats()
+	if len(chats) != 1 {
+		t.Fatalf("canais criados = %d, esperado 1", len(chats))
+	}
+	return srv.Chat(chats[0])
+}
+
+// captions lists the first line of the media messages of the chat
+func captions(chat *fake.Chat) []string {
+	var list []string
+	for _, m := range chat.Messages {
+		if m.Kind == fake.KindText {
+			continue
+		}
+		first, _, _ := strings.Cut(m.Text, "\n")
+		list = append(list, first)
+	}
+	return list
+}
+
+func messageWithPrefix(chat *fake.Chat, prefix string) *fake.Message {
+	for _, m := range chat.Messages {
+		if strings.HasPrefix(m.Text, prefix) {
+			return m
+		}
+	}
+	return nil
+}
+
+func TestUploadPostsTheCourseInOrder(t *testing.T) {
+	srv := setup(t)
+	course := defaultCourse(t)
+
+	if err := run(t, cmdUpload, course); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	chat := onlyChat(t, srv)
+	if chat.Title != "Curso de Go" {
+		t.Errorf("título do canal = %q", chat.Title)
+	}
+
+	want := []string{
+		"#Doc001 🗂 <b>Material de Apoio</b>",
+		"#F001 1 - 01 Instalação",
+		"#F002 2 - 02 Hello World",
+		"#F003 3 - 01 Variáveis",
+	}
+	if got := captions(chat); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("mensagens = %q, esperado %q", got, want)
+	}
+
+	// The pinned index links every video
+	if chat.Pinned == 0 {
+		t.Fatal("nenhuma mensagem fixada")
+	}
+	var menu *fake.Message
+	for _, m := range chat.Messages {
+		if m.ID == chat.Pinned {
+			menu = m
+		}
+	}
+	for _, prefix := range want[1:] {
+		video := messageWithPrefix(chat, prefix)
+		link := fmt.Sprintf("https://t.me/c/%d/%d", chat.ID, video.ID)
+		if !strings.Contains(menu.Text, link) {
+			t.Errorf("menu sem o link de %s (%s)", prefix, link)
+		}
+	}
+
+	if chat.InviteLink == "" || !strings.Contains(chat.About, chat.InviteLink) {
+		t.Errorf("descrição do canal sem o convite: %q", chat.About)
+	}
+
+	saved := srv.Chat(fake.SavedMessages)
+	if len(saved.Messages) != 1 || !strings.Contains(saved.Messages[0].Text, "Curso de Go") {
+		t.Errorf("anúncio não postado no Saved Messages: %+v", saved.Messages)
+	}
+
+	// The zip and the splitted parts never stay behind
+	if _, err := os.Stat("Arquivos.zip"); !os.IsNotExist(err) {
+		t.Error("Arquivos.zip não foi removido")
+	}
+}
+
+func TestUploadToExistingChat(t *testing.T) {
+	srv := setup(t)
+	srv.AddChat(-100123, "Canal Existente")
+	srv.AddChat(-100999, "Divulgação")
+	course := defaultCourse(t)
+
+	if err := run(t, cmdUpload, "--chat-id", "-100123", "--post-group", "-100999", course); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	if got := len(srv.Chat(-100123).Messages); got != 5 {
+		t.Errorf("mensagens no canal = %d, esperado 5 (zip, 3 vídeos e menu)", got)
+	}
+	if got := len(srv.Chat(-100999).Messages); got != 1 {
+		t.Errorf("anúncios no grupo de divulgação = %d, esperado 1", got)
+	}
+}
+
+func TestResumeAfterFailedUpload(t *testing.T) {
+	srv := setup(t)
+	course := defaultCourse(t)
+
+	srv.FailUpload = func(name string) error {
+		if name == "02 Hello World.mp4" {
+			return errors.New("conexão perdida")
+		}
+		return nil
+	}
+
+	err := run(t, cmdUpload, course)
+	if code := exitCode(err); code != exitPartial {
+		t.Fatalf("código de saída = %d (%v), esperado %d", code, err, exitPartial)
+	}
+
+	chat := onlyChat(t, srv)
+	if got := captions(chat); len(got) != 2 {
+		t.Fatalf("mensagens antes do resume = %q, esperado o zip e o primeiro vídeo", got)
+	}
+
+	srv.FailUpload = nil
+	if err := run(t, cmdResume, course); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+
+	// Same channel, nothing posted twice
+	chat = onlyChat(t, srv)
+	want := []string{
+		"#Doc001 🗂 <b>Material de Apoio</b>",
+		"#F001 1 - 01 Instalação",
+		"#F002 2 - 02 Hello World",
+		"#F003 3 - 01 Variáveis",
+	}
+	if got := captions(chat); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("mensagens = %q, esperado %q", got, want)
+	}
+}
+
+func TestResumeWithoutStateFails(t *testing.T) {
+	srv := setup(t)
+	course := defaultCourse(t)
+
+	err := run(t, cmdResume, course)
+	if code := exitCode(err); code != exitUsage {
+		t.Fatalf("código de saída = %d (%v), esperado %d", code, err, exitUsage)
+	}
+	if len(srv.Chats()) != 0 {
+		t.Error("resume sem estado criou um canal")
+	}
+}
+
+func TestVerifyFindsDeletedMessages(t *testing.T) {
+	srv := setup(t)
+	course := defaultCourse(t)
+
+	if err := run(t, cmdUpload, course); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := run(t, cmdVerify, course); err != nil {
+		t.Fatalf("verify logo depois do upload: %v", err)
+	}
+
+	chat := onlyChat(t, srv)
+	srv.DeleteMessage(chat.ID, messageWithPrefix(chat, "#F002").ID)
+
+	err := run(t, cmdVerify, course)
+	if code := exitCode(err); code != exitPartial {
+		t.Fatalf("código de saída = %d (%v), esperado %d", code, err, exitPartial)
+	}
+}
+
+func TestReindexPinsANewMenu(t *testing.T) {
+	srv := setup(t)
+	course := defaultCourse(t)
+
+	if err := run(t, cmdUpload, course); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	before := onlyChat(t, srv)
+
+	if err := run(t, cmdReindex, course); err != nil {
+		t.Fatalf("reindex: %v", err)
+	}
+
+	after := onlyChat(t, srv)
+	if after.Pinned == before.Pinned {
+		t.Fatal("o menu fixado não mudou")
+	}
+	if got := len(captions(after)); got != 4 {
+		t.Errorf("reindex reenviou arquivos: %d mídias no canal", got)
+	}
+
+	menu := after.Messages[len(after.Messages)-1]
+	for _, tag := range []string{"#Doc001", "#F001", "#F002", "#F003"} {
+		msg := messageWithPrefix(after, tag)
+		link := fmt.Sprintf("https://t.me/c/%d/%d", after.ID, msg.ID)
+		if !strings.Contains(menu.Text, link) {
+			t.Errorf("menu sem o link de %s", tag)
+		}
+	}
+}
+
+func TestPlanWritesNothing(t *testing.T) {
+	srv := setup(t)
+	course := defaultCourse(t)
+
+	if err := cmdPlan(context.Background(), []string{"--json", "--no-probe", course}); err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+
+	entries, _ := os.ReadDir(".")
+	if len(entries) != 1 || entries[0].Name() != course {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("plan escreveu arquivos: %v", names)
+	}
+	if len(srv.Chats()) != 0 {
+		t.Error("plan usou o Telegram")
+	}
+}
+
+func TestStatusWritesNothing(t *testing.T) {
+	setup(t)
+	course := defaultCourse(t)
+
+	err := cmdStatus(context.Background(), []string{"--state-backend", "sqlite", "--chat", "-100123", "--runs", "24h", course})
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+
+	entries, _ := os.ReadDir(".")
+	if len(entries) != 1 || entries[0].Name() != course {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("status escreveu arquivos: %v", names)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	srv := setup(t)
+	course := defaultCourse(t)
+
+	err := cmdUpload(context.Background(), []string{course})
+	if code := exitCode(err); code != exitConfig {
+		t.Errorf("sem credenciais: código = %d (%v), esperado %d", code, err, exitConfig)
+	}
+
+	err = run(t, cmdUpload, "--upload-workers", "0", course)
+	if code := exitCode(err); code != exitConfig {
+		t.Errorf("workers inválido: código = %d (%v), esperado %d", code, err, exitConfig)
+	}
+
+	err = run(t, cmdUpload, "--nao-existe", course)
+	if code := exitCode(err); code != exitUsage {
+		t.Errorf("flag desconhecida: código = %d (%v), esperado %d", code, err, exitUsage)
+	}
+
+	srv.AuthErr = errors.New("PHONE_CODE_INVALID")
+	err = run(t, cmdLogin)
+	if code := exitCode(err); code != exitAuth {
+		t.Errorf("login falhou: código = %d (%v), esperado %d", code, err, exitAuth)
+	}
+}
+
+func TestOldZipsOnlyMatchesTheAssetsZip(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"Arquivos.zip", "Arquivos.part2.zip", "Arquivos-2023.zip", "Arquivos.zip.bak", "Meus Arquivos.zip"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var names []string
+	for _, path := range oldZips(dir) {
+		names = append(names, filepath.Base(path))
+	}
+	if strings.Join(names, ",") != "Arquivos.part2.zip,Arquivos.zip" {
+		t.Errorf("oldZips = %v, esperado só os zips do material de apoio", names)
+	}
+}
+
+func TestCleanAsksBeforeDeleting(t *testing.T) {
+	setup(t)
+	course := writeCourse(t, "Curso", "01 a.mp4", "01 a-part-001.mp4", "Aula 5-part-2.mp4")
+	args := []string{"--work-dir", t.TempDir(), course}
+
+	previous := stdin
+	t.Cleanup(func() { stdin = previous })
+
+	stdin = strings.NewReader("n\n")
+	if err := cmdClean(context.Background(), args); err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(course, "01 a-part-001.mp4")); err != nil {
+		t.Errorf("clean apagou sem confirmação: %v", err)
+	}
+
+	stdin = strings.NewReader("s\n")
+	if err := cmdClean(context.Background(), args); err != nil {
+		t.Fatalf("clean: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(course, "01 a-part-001.mp4")); !os.IsNotExist(err) {
+		t.Errorf("parte não apagada após a confirmação: %v", err)
+	}
+	for _, name := range []string{"01 a.mp4", "Aula 5-part-2.mp4"} {
+		if _, err := os.Stat(filepath.Join(course, name)); err != nil {
+			t.Errorf("clean apagou %s: %v", name, err)
+		}
+	}
+}
+
+// writeProgress saves a json state with the fingerprints of the files already uploaded
+func writeProgress(t *testing.T, name string, files ...string) string {
+	t.Helper()
+	if err := os.MkdirAll("session", 0700); err != nil {
+		t.Fatal(err)
+	}
+	pm, err := state.Load(state.ProgressPath(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, path := range files {
+		fp, err := state.Fingerprint(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pm.MarkAsUploaded(fp, state.PartRecord{Path: path, MessageID: i + 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return pm.FilePath
+}
+
+func TestRelocateNeedsMostOfTheVideos(t *testing.T) {
+	setup(t)
+	root := writeCourse(t, "Curso novo", "a.mp4", "b.mp4", "c.mp4")
+	course, err := scanner.New(root).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := state.ProgressPath("Curso novo")
+
+	// Another course that shares only the intro
+	writeProgress(t, "Outro curso", filepath.Join(root, "a.mp4"))
+	relocateProgress(course, "Curso novo")
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("estado de outro curso copiado com 1 de 3 vídeos: %v", err)
+	}
+
+	old := writeProgress(t, "Curso antigo", filepath.Join(root, "a.mp4"), filepath.Join(root, "b.mp4"))
+	relocateProgress(course, "Curso novo")
+	if _, err := os.Stat(target); err != nil {
+		t.Fatalf("estado renomeado não encontrado: %v", err)
+	}
+	if _, err := os.Stat(old); err != nil {
+		t.Errorf("estado antigo apagado: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FolcloreX/GopherGram/internal/telegram"
+)
+
+func cmdLogin(ctx context.Context, args []string) error {
+	fs := newFlagSet("login", "",
+		"Faz o login na conta (código + senha 2FA) e salva a sessão em session/.\n"+
+			"Os outros comandos reutilizam a sessão sem pedir o código.")
+	cf := bindConfigFlags(fs)
+	forceUnlock := fs.Bool("force-unlock", false, "remove o lock da sessão deixado por outro processo")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return usageError("login não recebe argumentos")
+	}
+
+	cfg, err := cf.load(true)
+	if err != nil {
+		return err
+	}
+
+	release, err := acquireLocks("", cfg.Phone, *forceUnlock)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	bot := newTelegram(cfg)
+	return bot.Start(ctx, func(ctx context.Context) error {
+		fmt.Printf("✅ Sessão salva em %s\n", telegram.SessionPath(cfg.Phone))
+		return nil
+	})
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/FolcloreX/GopherGram/internal/domain"
+	"github.com/FolcloreX/GopherGram/internal/plan"
+	"github.com/FolcloreX/GopherGram/internal/processor"
+	"github.com/FolcloreX/GopherGram/internal/state"
+)
+
+func cmdPlan(ctx context.Context, args []string) error {
+	fs := newFlagSet("plan", "<pasta do curso>",
+		"Mostra o plano do upload: módulos, vídeos, partes, material de apoio, tamanho,\n"+
+			"duração e o que já foi enviado segundo o estado.\n"+
+			"Não conecta no Telegram e não escreve nenhum arquivo.")
+	cf := bindConfigFlags(fs)
+	asJSON := fs.Bool("json", false, "mostra o plano em JSON")
+	noProbe := fs.Bool("no-probe", false, "não calcula a duração com o ffprobe (mais rápido)")
+	premium := fs.Bool("premium", false, "a conta é Premium: com SIZE_LIMIT=premium as partes são de 4GB")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return usageError("informe a pasta do curso")
+	}
+
+	cfg, err := cf.load(false)
+	if err != nil {
+		return err
+	}
+
+	// With --json only the plan goes to stdout
+	var info io.Writer = os.Stdout
+	if *asJSON {
+		info = os.Stderr
+	}
+
+	_, course, err := loadCourseTo(info, cfg, positional[0])
+	if err != nil {
+		return err
+	}
+
+	snap, err := readPlanSnapshot(cfg.StateBackend, cfg.StateDB, course)
+	if err != nil {
+		return fmt.Errorf("erro no estado: %w", err)
+	}
+
+	// Offline we can't check the account, upload only uses 4GB when it's Premium
+	opts := plan.Options{
+		MaxFileSize: domain.FileSizeLimit(cfg.SizeLimit, *premium),
+		Probe:       processor.VideoDuration,
+	}
+	if cfg.SizeLimit == domain.SizePremium {
+		if *premium {
+			fmt.Fprintf(info, "📏 SIZE_LIMIT=premium: partes de até %s, supondo conta Premium\n", processor.FormatSize(opts.MaxFileSize))
+		} else {
+			fmt.Fprintf(info, "📏 SIZE_LIMIT=premium: partes de até %s, supondo conta sem Premium (use --premium se ela for)\n", processor.FormatSize(opts.MaxFileSize))
+		}
+	}
+	if *noProbe {
+		opts.Probe = nil
+	} else {
+		fmt.Fprintln(info, "⏱  Calculando duração dos vídeos...")
+	}
+
+	p, err := plan.Build(course, snap, opts)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return p.WriteJSON(os.Stdout)
+	}
+	return p.WriteTable(os.Stdout)
+}
+
+// readPlanSnapshot reads the state without writing, including the state of the
+// course under its old name when the folder was renamed (json backend).
+func readPlanSnapshot(backend, dbPath string, course *domain.Course) (*state.Snapshot, error) {
+	contentName, err := courseName(course.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := state.ReadSnapshot(backend, dbPath, contentName)
+	if err != nil || snap != nil || backend != state.BackendJSON {
+		return snap, err
+	}
+
+	found, err := findRenamedProgress(course)
+	if err != nil || found == "" {
+		return nil, err
+	}
+	return state.ReadJSONSnapshot(found)
+}
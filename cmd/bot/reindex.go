@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/FolcloreX/GopherGram/pipeline"
+)
+
+func cmdReindex(ctx context.Context, args []string) error {
+	fs := newFlagSet("reindex", "<pasta do curso>",
+		"Monta o menu de novo com as mensagens salvas no estado, envia e fixa.\n"+
+			"Nada é reenviado, os vídeos que ainda não subiram ficam de fora.")
+	cf := bindConfigFlags(fs)
+	forceUnlock := fs.Bool("force-unlock", false, "remove os locks do curso e da sessão deixados por outro processo")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return usageError("informe a pasta do curso")
+	}
+
+	cfg, err := cf.load(true)
+	if err != nil {
+		return err
+	}
+
+	rootDir, course, err := loadCourse(cfg, positional[0])
+	if err != nil {
+		return err
+	}
+	contentName := filepath.Base(rootDir)
+
+	ws, err := openWorkspace(cfg, course, contentName, cfg.Phone, *forceUnlock)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	useSavedChat(cfg, ws.prog)
+	if cfg.ChatID == 0 {
+		return usageError("nenhum canal salvo para %s, informe --chat-id", contentName)
+	}
+
+	bot := newTelegram(cfg)
+	return bot.Start(ctx, func(ctx context.Context) error {
+		// The links need the peer of the chat
+		if err := bot.CheckChatAccess(ctx); err != nil {
+			return fmt.Errorf("erro ao acessar chat origem: %w", err)
+		}
+
+		menu, missing := pipeline.RebuildMenu(bot, cfg.IndexStyle, course, ws.prog.Records())
+		if missing > 0 {
+			log.Printf("⚠️ %d vídeo(s) ainda não enviados ficaram fora do menu", missing)
+		}
+
+		return pipeline.SendIndex(ctx, bot, menu)
+	})
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/FolcloreX/GopherGram/internal/processor"
+	"github.com/FolcloreX/GopherGram/internal/state"
+)
+
+func cmdStatus(ctx context.Context, args []string) error {
+	fs := newFlagSet("status", "[pasta do curso]",
+		"Mostra o estado salvo do curso: canal, arquivos enviados e tamanho.\n"+
+			"Com o backend sqlite também lista os cursos de um chat (--chat) e o histórico (--runs).")
+	cf := bindConfigFlags(fs)
+	files := fs.Bool("files", false, "lista cada arquivo enviado")
+	chat := fs.Int64("chat", 0, "lista os cursos enviados para o chat (sqlite)")
+	runs := fs.Duration("runs", 0, "lista as execuções do período, ex: 24h (sqlite)")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 || (len(positional) == 0 && *chat == 0 && *runs == 0) {
+		fs.Usage()
+		return usageError("informe a pasta do curso (ou --chat/--runs)")
+	}
+
+	cfg, err := cf.load(false)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) == 1 {
+		contentName, err := courseName(positional[0])
+		if err != nil {
+			return err
+		}
+		if err := printCourseStatus(cfg.StateBackend, cfg.StateDB, contentName, *files); err != nil {
+			return err
+		}
+	}
+
+	if *chat == 0 && *runs == 0 {
+		return nil
+	}
+	if cfg.StateBackend != state.BackendSQLite {
+		return usageError("--chat e --runs precisam do backend sqlite (STATE_BACKEND=sqlite)")
+	}
+
+	dbPath := cfg.StateDB
+	if dbPath == "" {
+		dbPath = state.DefaultDBPath
+	}
+	db, err := state.ReadSQLiteDB(dbPath)
+	if err != nil {
+		return err
+	}
+	if db == nil {
+		fmt.Printf("\n📭 Nenhum estado em %s\n", dbPath)
+		return nil
+	}
+	defer db.Close()
+
+	if *chat != 0 {
+		courses, err := db.CoursesByChat(*chat)
+		if err != nil {
+			return fmt.Errorf("erro ao listar cursos: %w", err)
+		}
+		fmt.Printf("\n💬 Cursos no chat %d: %d\n", *chat, len(courses))
+		for _, c := range courses {
+			fmt.Printf("   📂 %s | %d arquivo(s) | desde %s\n", c.Name, c.Parts, c.CreatedAt.Format(time.DateTime))
+		}
+	}
+
+	if *runs != 0 {
+		history, err := db.Runs(time.Now().Add(-*runs), "")
+		if err != nil {
+			return fmt.Errorf("erro ao listar execuções: %w", err)
+		}
+		fmt.Printf("\n🕒 Execuções nas últimas %s: %d\n", *runs, len(history))
+		for _, r := range history {
+			line := fmt.Sprintf("   %s | %-7s | %s | %s | %d enviado(s)",
+				r.StartedAt.Format(time.DateTime), r.Status, r.Course, r.Host, r.Uploaded)
+			if r.Error != "" {
+				line += " | " + r.Error
+			}
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
+
+func printCourseStatus(backend, dbPath, contentName string, listFiles bool) error {
+	fmt.Printf("\n📂 Curso: %s\n", contentName)
+
+	snap, err := state.ReadSnapshot(backend, dbPath, contentName)
+	if err != nil {
+		return fmt.Errorf("erro no estado: %w", err)
+	}
+	if snap == nil {
+		fmt.Println("📭 Nenhum upload registrado para este curso.")
+		return nil
+	}
+	records := snap.Records
+
+	var totalSize int64
+	var last time.Time
+	for _, rec := range records {
+		totalSize += rec.Size
+		if rec.UploadedAt.After(last) {
+			last = rec.UploadedAt
+		}
+	}
+
+	fmt.Printf("💾 Estado: %s\n", snap.Location)
+	fmt.Printf("💬 Chat: %d\n", snap.ChatID)
+	fmt.Printf("📦 Arquivos enviados: %d (%s)\n", len(records), processor.FormatSize(totalSize))
+	if !last.IsZero() {
+		fmt.Printf("🕒 Último envio: %s\n", last.Local().Format(time.DateTime))
+	}
+
+	if !listFiles {
+		return nil
+	}
+
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return records[keys[i]].MessageID < records[keys[j]].MessageID
+	})
+
+	fmt.Println()
+	for _, key := range keys {
+		rec := records[key]
+		name := filepath.Base(rec.Path)
+		if rec.Path == "" {
+			name = key
+		}
+		fmt.Printf("   #%-6d %10s  %s\n", rec.MessageID, processor.FormatSize(rec.Size), name)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/FolcloreX/GopherGram/pipeline"
+)
+
+func cmdUpload(ctx context.Context, args []string) error {
+	return runUpload(ctx, "upload", args)
+}
+
+func cmdResume(ctx context.Context, args []string) error {
+	return runUpload(ctx, "resume", args)
+}
+
+// runUpload is the whole flow: assets, videos, index and announcement.
+// resume is the same flow, but refuses to start a course from zero.
+func runUpload(ctx context.Context, name string, args []string) error {
+	description := "Envia o curso: material de apoio, vídeos, menu e anúncio.\n" +
+		"Se ORIGIN_CHAT_ID estiver vazio usa o canal salvo no estado, ou cria um novo."
+	if name == "resume" {
+		description = "Continua um upload interrompido no canal salvo no estado.\n" +
+			"Os arquivos já enviados são pulados."
+	}
+
+	fs := newFlagSet(name, "<pasta do curso> [capa.jpg]", description)
+	cf := bindConfigFlags(fs)
+	cover := fs.String("cover", "", "imagem usada como foto do canal e no anúncio")
+	forceUnlock := fs.Bool("force-unlock", false, "remove os locks do curso e da sessão deixados por outro processo")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 1 || len(positional) > 2 {
+		fs.Usage()
+		return usageError("informe a pasta do curso")
+	}
+
+	// Argument 2: Cover Path (Opcional)
+	coverPath := *cover
+	if len(positional) == 2 {
+		coverPath = positional[1]
+	}
+	if coverPath != "" {
+		if _, err := os.Stat(coverPath); os.IsNotExist(err) {
+			log.Printf("⚠️ Aviso: Capa informada não existe: %s (será enviado como texto)", coverPath)
+			coverPath = ""
+		}
+	}
+
+	cfg, err := cf.load(true)
+	if err != nil {
+		return err
+	}
+
+	rootDir, course, err := loadCourse(cfg, positional[0])
+	if err != nil {
+		return err
+	}
+
+	// ContentName = Name of the Root Folder
+	contentName := filepath.Base(rootDir)
+	if coverPath == "" {
+		coverPath = course.CoverPath
+	}
+
+	fmt.Printf("\n🚀 GOPHERGRAM UPLOADER\n📂 Curso: %s\n🖼 Capa: %s\n\n", course.Title, coverPath)
+
+	ws, err := openWorkspace(cfg, course, contentName, cfg.Phone, *forceUnlock)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+	prog := ws.prog
+
+	if name == "resume" && prog.GetChatID() == 0 && len(prog.Records()) == 0 {
+		return usageError("nenhum upload anterior de %s encontrado em %s (use upload)", contentName, prog.Location())
+	}
+	useSavedChat(cfg, prog)
+
+	// The convert of the course.yaml wins over the .env, it's specific of the course
+	convert := cfg.VideoConvert
+	if course.Convert != "" {
+		convert = course.Convert
+	}
+
+	_, err = pipeline.Run(ctx, course, pipeline.Options{
+		Telegram:       newTelegram(cfg),
+		Store:          prog,
+		Title:          course.Title,
+		CoverPath:      coverPath,
+		Logo:           cfg.Logo,
+		IndexStyle:     cfg.IndexStyle,
+		PrepareWorkers: cfg.PrepareWorkers,
+		UploadWorkers:  cfg.UploadWorkers,
+		SubtitleMode:   cfg.SubtitleMode,
+		SubtitleLang:   cfg.SubtitleLang,
+		Convert:        convert,
+		SizeLimit:      cfg.SizeLimit,
+		WorkDir:        cfg.WorkDir,
+	})
+
+	// What was sent is in the state, resume continues from there
+	var phaseErr *pipeline.PhaseError
+	if errors.As(err, &phaseErr) {
+		return withCode(exitPartial, err)
+	}
+	return err
+}
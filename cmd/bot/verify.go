@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/FolcloreX/GopherGram/internal/state"
+)
+
+func cmdVerify(ctx context.Context, args []string) error {
+	fs := newFlagSet("verify", "<pasta do curso>",
+		"Confere no canal se cada mensagem salva no estado ainda existe.\n"+
+			"Termina com o código 5 se alguma sumiu (use resume depois de limpar o estado).")
+	cf := bindConfigFlags(fs)
+	forceUnlock := fs.Bool("force-unlock", false, "remove o lock da sessão deixado por outro processo")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return usageError("informe a pasta do curso")
+	}
+
+	cfg, err := cf.load(true)
+	if err != nil {
+		return err
+	}
+
+	contentName, err := courseName(positional[0])
+	if err != nil {
+		return err
+	}
+
+	exists, err := state.Exists(cfg.StateBackend, cfg.StateDB, contentName)
+	if err != nil {
+		return fmt.Errorf("erro no estado: %w", err)
+	}
+	if !exists {
+		return usageError("nenhum upload registrado para %s", contentName)
+	}
+
+	// Only reading the state, the course lock is not needed
+	release, err := acquireLocks("", cfg.Phone, *forceUnlock)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	prog, err := state.OpenStore(cfg.StateBackend, cfg.StateDB, contentName)
+	if err != nil {
+		return fmt.Errorf("erro no estado: %w", err)
+	}
+	defer prog.Close()
+
+	useSavedChat(cfg, prog)
+	records := prog.Records()
+
+	var ids []int
+	for _, rec := range records {
+		if rec.MessageID != 0 {
+			ids = append(ids, rec.MessageID)
+		}
+	}
+	if len(records) > len(ids) {
+		fmt.Printf("⚠️  %d arquivo(s) enviados por versões antigas não têm mensagem salva\n", len(records)-len(ids))
+	}
+
+	bot := newTelegram(cfg)
+	return bot.Start(ctx, func(ctx context.Context) error {
+		if err := bot.CheckChatAccess(ctx); err != nil {
+			return fmt.Errorf("erro ao acessar chat origem: %w", err)
+		}
+
+		fmt.Printf("🔎 Conferindo %d mensagem(ns)...\n", len(ids))
+		found, err := bot.FetchMessages(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar mensagens: %w", err)
+		}
+
+		var missing []string
+		for key, rec := range records {
+			if rec.MessageID == 0 {
+				continue
+			}
+			msg, ok := found[rec.MessageID]
+			if !ok {
+				missing = append(missing, fmt.Sprintf("#%d %s", rec.MessageID, recordName(key, rec)))
+				continue
+			}
+			if rec.DocumentID != 0 && msg.DocumentID != rec.DocumentID {
+				missing = append(missing, fmt.Sprintf("#%d %s (arquivo trocado)", rec.MessageID, recordName(key, rec)))
+			}
+		}
+
+		if len(missing) == 0 {
+			fmt.Printf("✅ Todas as %d mensagens estão no canal\n", len(ids))
+			return nil
+		}
+
+		sort.Strings(missing)
+		fmt.Printf("❌ %d mensagem(ns) não encontrada(s):\n", len(missing))
+		for _, m := range missing {
+			fmt.Printf("   %s\n", m)
+		}
+		return withCode(exitPartial, fmt.Errorf("%d de %d mensagens faltando no canal", len(missing), len(ids)))
+	})
+}
+
+// recordName is the file name of the record, or its key for old records without path
+func recordName(key string, rec state.PartRecord) string {
+	if rec.Path == "" {
+		return key
+	}
+	return filepath.Base(rec.Path)
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
+
+	"github.com/FolcloreX/GopherGram/internal/domain"
 )
 
 type Config struct {
@@ -17,16 +20,46 @@ type Config struct {
 	Logo             string
 	PostGroupID      int64
 	PostGroupTopicID int
+	PrepareWorkers   int // Videos being splitted/thumbnailed at the same time
+	UploadWorkers    int // Files being uploaded at the same time
+	IndexStyle       string
+	StateBackend     string   // "json" (default) or "sqlite"
+	StateDB          string   // Database file of the sqlite backend
+	Include          []string // Globs of the files to upload, empty uploads everything
+	Exclude          []string // Globs of the files and folders left out
+	ModuleDepth      int      // Levels of subfolders that become modules, 0 is no limit
+	RootModule       string   // Module of the videos in the root of the course
+	SubtitleMode     string   // "reply" (default), "mux" or "burn"
+	SubtitleLang     string   // Subtitle burned when a video has more than one
+	VideoConvert     string   // What to do with the videos Telegram can't stream: none, remux (default) or transcode
+	SizeLimit        string   // "compatible" (default, 2GB files) or "premium" (4GB when the account is Premium)
+	WorkDir          string   // Where the run folders (zip, parts, thumbnails, converted copies) are created, the temp folder of the system if empty
 }
 
+const (
+	DefaultPrepareWorkers = 2
+	DefaultUploadWorkers  = 2
+)
+
 func Load() (*Config, error) {
-	if err := godotenv.Load(); err != nil {
-		return nil, fmt.Errorf("erro ao carregar arquivo .env: %w", err)
+	return LoadFile(".env")
+}
+
+// LoadFile reads the config from the env file and the environment.
+// The file is optional since every field can also come from the CLI flags,
+// call Validate after applying them.
+func LoadFile(envFile string) (*Config, error) {
+	if err := godotenv.Load(envFile); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("erro ao carregar arquivo %s: %w", envFile, err)
 	}
 
-	apiID, err := strconv.Atoi(os.Getenv("API_ID"))
-	if err != nil {
-		return nil, fmt.Errorf("API_ID inválido no .env: %w", err)
+	var apiID int
+	if val := os.Getenv("API_ID"); val != "" {
+		id, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("API_ID inválido no .env: %w", err)
+		}
+		apiID = id
 	}
 
 	// Opcional
@@ -49,6 +82,62 @@ func Load() (*Config, error) {
 
 	logo := os.Getenv("LOGO")
 
+	// Opcional
+	prepareWorkers := DefaultPrepareWorkers
+	if val := os.Getenv("PREPARE_WORKERS"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			prepareWorkers = n
+		}
+	}
+
+	// Opcional
+	uploadWorkers := DefaultUploadWorkers
+	if val := os.Getenv("UPLOAD_WORKERS"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			uploadWorkers = n
+		}
+	}
+
+	// Opcional: "links" (default) or "hashtags"
+	indexStyle := os.Getenv("INDEX_STYLE")
+	if indexStyle == "" {
+		indexStyle = "links"
+	}
+
+	// Opcional: 0 (default) keeps every level of subfolders as a module
+	var moduleDepth int
+	if val := os.Getenv("MODULE_DEPTH"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("MODULE_DEPTH inválido no .env: %w", err)
+		}
+		moduleDepth = n
+	}
+
+	// Opcional
+	subtitleMode := os.Getenv("SUBTITLE_MODE")
+	if subtitleMode == "" {
+		subtitleMode = "reply"
+	}
+
+	// Opcional
+	videoConvert := os.Getenv("VIDEO_CONVERT")
+	if videoConvert == "" {
+		videoConvert = domain.ConvertRemux
+	}
+
+	// Opcional
+	sizeLimit := os.Getenv("SIZE_LIMIT")
+	if sizeLimit == "" {
+		sizeLimit = domain.SizeCompatible
+	}
+
+	// Opcional
+	stateBackend := os.Getenv("STATE_BACKEND")
+	if stateBackend == "" {
+		stateBackend = "json"
+	}
+
 	cfg := &Config{
 		APIID:            apiID,
 		APIHash:          os.Getenv("API_HASH"),
@@ -58,11 +147,70 @@ func Load() (*Config, error) {
 		Logo:             logo,
 		PostGroupID:      postGroupID,
 		PostGroupTopicID: postTopicID,
+		PrepareWorkers:   prepareWorkers,
+		UploadWorkers:    uploadWorkers,
+		IndexStyle:       indexStyle,
+		StateBackend:     stateBackend,
+		StateDB:          os.Getenv("STATE_DB"),
+		Include:          splitList(os.Getenv("INCLUDE")),
+		Exclude:          splitList(os.Getenv("EXCLUDE")),
+		ModuleDepth:      moduleDepth,
+		RootModule:       os.Getenv("ROOT_MODULE_NAME"),
+		SubtitleMode:     subtitleMode,
+		SubtitleLang:     os.Getenv("SUBTITLE_LANG"),
+		VideoConvert:     videoConvert,
+		SizeLimit:        sizeLimit,
+		WorkDir:          os.Getenv("WORK_DIR"),
 	}
 
-	if cfg.APIHash == "" || cfg.Phone == "" {
-		return nil, fmt.Errorf("API_HASH e PHONE_NUMBER devem ser definidos no .env")
+	return cfg, nil
+}
+
+// Validate checks the required fields and the allowed values
+func (c *Config) Validate() error {
+	if c.APIID == 0 {
+		return fmt.Errorf("API_ID deve ser definido no .env ou com --api-id")
+	}
+	if c.APIHash == "" || c.Phone == "" {
+		return fmt.Errorf("API_HASH e PHONE_NUMBER devem ser definidos no .env (ou --api-hash e --phone)")
 	}
+	return c.ValidateOptions()
+}
 
-	return cfg, nil
+// ValidateOptions checks only the values that don't need Telegram,
+// used by the commands that work offline.
+func (c *Config) ValidateOptions() error {
+	if c.PrepareWorkers < 1 || c.UploadWorkers < 1 {
+		return fmt.Errorf("PREPARE_WORKERS e UPLOAD_WORKERS devem ser maiores que zero")
+	}
+	if c.ModuleDepth < 0 {
+		return fmt.Errorf("MODULE_DEPTH não pode ser negativo")
+	}
+	if c.IndexStyle != "links" && c.IndexStyle != "hashtags" {
+		return fmt.Errorf("INDEX_STYLE inválido: %q (use links ou hashtags)", c.IndexStyle)
+	}
+	if c.StateBackend != "json" && c.StateBackend != "sqlite" {
+		return fmt.Errorf("STATE_BACKEND inválido: %q (use json ou sqlite)", c.StateBackend)
+	}
+	if c.SubtitleMode != "reply" && c.SubtitleMode != "mux" && c.SubtitleMode != "burn" {
+		return fmt.Errorf("SUBTITLE_MODE inválido: %q (use reply, mux ou burn)", c.SubtitleMode)
+	}
+	if !domain.IsConvertPolicy(c.VideoConvert) {
+		return fmt.Errorf("VIDEO_CONVERT inválido: %q (use none, remux ou transcode)", c.VideoConvert)
+	}
+	if !domain.IsSizePolicy(c.SizeLimit) {
+		return fmt.Errorf("SIZE_LIMIT inválido: %q (use compatible ou premium)", c.SizeLimit)
+	}
+	return nil
+}
+
+// splitList reads a comma separated list, ignoring the empty items
+func splitList(val string) []string {
+	var list []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
 }
@@ -7,26 +7,132 @@ import (
 )
 
 const (
-	MaxFileSize  int64 = 2 * 1024 * 1024 * 1024 // MaxFileSize 2GB
-	HashTagVideo       = "F"                    // Ex: #F001
-	HashTagDoc         = "Doc"                  // Ex: #Doc001
+	MaxFileSize        int64 = 2000 * 1024 * 1024 // Telegram's cap: 4000 chunks of 512KB (2,097,152,000 bytes), a bit less than 2GiB
+	MaxPremiumFileSize int64 = 4000 * 1024 * 1024 // Cap of the Premium accounts: 8000 chunks of 512KB (4,194,304,000 bytes)
+	HashTagVideo             = "F"                // Ex: #F001
+	HashTagDoc               = "Doc"              // Ex: #Doc001
 )
 
+// How big the uploaded files can be, before being splitted
+const (
+	SizeCompatible = "compatible" // MaxFileSize, every viewer can download the files easily
+	SizePremium    = "premium"    // MaxPremiumFileSize, when the account is Premium. Fewer parts
+)
+
+// IsSizePolicy reports if the value is one of the Size* policies
+func IsSizePolicy(val string) bool {
+	return val == SizeCompatible || val == SizePremium
+}
+
+// FileSizeLimit is the size of the files of the policy. The premium limit
+// is only possible when the account uploading is Premium.
+func FileSizeLimit(policy string, premium bool) int64 {
+	if policy == SizePremium && premium {
+		return MaxPremiumFileSize
+	}
+	return MaxFileSize
+}
+
 // Represents the entire struct of the Course being processed
 type Course struct {
 	RootPath string
 	Modules  []*Module
 	Assets   []string // Non videos
+	Ignored  []IgnoredFile
+
+	// Info of the course, from the manifest (course.yaml). Title defaults to the folder name
+	Title       string
+	Description string
+	Author      string
+	Year        int
+	CoverPath   string
+	Convert     string // Policy for the videos Telegram can't stream, empty uses the config
 }
 
-// Represente each folder of the Course being processed
+// How the videos that Telegram can't stream (.mkv, .avi, HEVC in .mov...) are sent
+const (
+	ConvertNone      = "none"      // As they are, played only after the download
+	ConvertRemux     = "remux"     // Streams copied into an MP4 when the codecs allow, lossless
+	ConvertTranscode = "transcode" // Remux, or re-encoded as H.264/AAC when it's not possible
+)
+
+// IsConvertPolicy reports if the value is one of the Convert* policies
+func IsConvertPolicy(val string) bool {
+	return val == ConvertNone || val == ConvertRemux || val == ConvertTranscode
+}
+
+// IgnoredFile is a file or folder left out of the course by an ignore rule
+type IgnoredFile struct {
+	Path   string
+	Reason string
+}
+
+// Represente each folder of the Course being processed.
+// The subfolders are child modules, up to the depth configured in the scanner.
 type Module struct {
 	Name     string
-	Videos   []*Video
+	Videos   []*Video // Only the videos of this folder, not of the children
 	Sequence int
+	Folder   string // Relative to the root, with "/"
+	Parent   *Module
+	Children []*Module
+}
+
+// Separator of the module names in the captions. Ex: Seção 1 › Boas-vindas
+const ModuleSeparator = " › "
+
+// FullName is the name of the module after the names of its parents
+func (m *Module) FullName() string {
+	if m.Parent == nil {
+		return m.Name
+	}
+	return m.Parent.FullName() + ModuleSeparator + m.Name
+}
+
+// Depth is 1 for the folders in the root, 2 for their subfolders...
+func (m *Module) Depth() int {
+	if m.Parent == nil {
+		return 1
+	}
+	return m.Parent.Depth() + 1
+}
+
+// AllModules lists every module of the course in the upload order,
+// each one followed by its children.
+func (c *Course) AllModules() []*Module {
+	var list []*Module
+	var walk func(mods []*Module)
+	walk = func(mods []*Module) {
+		for _, m := range mods {
+			list = append(list, m)
+			walk(m.Children)
+		}
+	}
+	walk(c.Modules)
+	return list
+}
+
+// Videos lists every video of the course in the upload order
+func (c *Course) Videos() []*Video {
+	var list []*Video
+	for _, m := range c.AllModules() {
+		list = append(list, m.Videos...)
+	}
+	return list
 }
 
+// MediaKind tells how a lesson is sent: as a video or in the audio player
+type MediaKind string
+
+const (
+	MediaVideo MediaKind = "video"
+	MediaAudio MediaKind = "audio" // Podcasts and audio lessons
+)
+
+// Video is a lesson of a module. Audio lessons are Videos too, with Kind MediaAudio,
+// so they are numbered and indexed in the same sequence.
 type Video struct {
+	Kind     MediaKind
 	FilePath string
 	FileName string
 	Title    string
@@ -35,16 +141,30 @@ type Video struct {
 	Size     int64
 	ID       string
 	Sequence int
+
+	Subtitles []Subtitle // Found next to the video, with the same name
 }
 
-func (v *Video) FormatCaption() string {
-	// Remove video extension to keep it clean
-	cleanTitle := strings.TrimSuffix(v.Title, filepath.Ext(v.Title))
+// Subtitle is a .srt/.vtt of a video. Ex: aula.srt, aula.pt-BR.srt
+type Subtitle struct {
+	Path string
+	Lang string // From the name, empty if it has none
+}
 
+func (v *Video) IsAudio() bool {
+	return v.Kind == MediaAudio
+}
+
+// CleanTitle is the title without the video extension
+func (v *Video) CleanTitle() string {
+	return strings.TrimSuffix(v.Title, filepath.Ext(v.FileName))
+}
+
+func (v *Video) FormatCaption() string {
 	return fmt.Sprintf("#%s %d - %s\n%s",
 		v.ID,
 		v.Sequence,
-		cleanTitle,
+		v.CleanTitle(),
 		v.Module,
 	)
 }
@@ -58,3 +178,31 @@ func IsVideo(filename string) bool {
 		return false
 	}
 }
+
+func IsAudio(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	switch ext {
+	case ".mp3", ".m4a", ".ogg", ".flac":
+		return true
+	default:
+		return false
+	}
+}
+
+// IsMedia reports if the file is a lesson (video or audio), not a support file
+func IsMedia(filename string) bool {
+	return IsVideo(filename) || IsAudio(filename)
+}
+
+// MediaKindOf is the kind of a file accepted by IsMedia
+func MediaKindOf(filename string) MediaKind {
+	if IsAudio(filename) {
+		return MediaAudio
+	}
+	return MediaVideo
+}
+
+func IsSubtitle(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	return ext == ".srt" || ext == ".vtt"
+}
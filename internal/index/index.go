@@ -0,0 +1,240 @@
+package index
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf16"
+)
+
+// Style defines how each entry of the menu is rendered
+type Style string
+
+const (
+	StyleLinks    Style = "links"    // Clickable title pointing to the message
+	StyleHashtags Style = "hashtags" // Only the hashtag, navigation through Telegram search
+)
+
+// Entry is one item of the menu (a video or a file)
+type Entry struct {
+	Tag   string // Ex: F001, Doc001 (without #)
+	Title string
+	Link  string // Empty when we don't know the message, falls back to the hashtag
+}
+
+// Section groups the entries of a module
+type Section struct {
+	Title   string
+	Level   int // 0 for the modules in the root, 1 for their children...
+	Entries []Entry
+}
+
+// Menu is the pinned index of the course
+type Menu struct {
+	Style    Style
+	Docs     []Entry
+	Sections []*Section
+}
+
+func New(style Style) *Menu {
+	if style != StyleHashtags {
+		style = StyleLinks
+	}
+	return &Menu{Style: style}
+}
+
+func (m *Menu) AddDoc(e Entry) {
+	m.Docs = append(m.Docs, e)
+}
+
+func (m *Menu) AddSection(title string) *Section {
+	s := &Section{Title: title}
+	m.Sections = append(m.Sections, s)
+	return s
+}
+
+func (s *Section) Add(e Entry) {
+	s.Entries = append(s.Entries, e)
+}
+
+// Render builds the HTML message of the menu
+func (m *Menu) Render() string {
+	var b strings.Builder
+	b.WriteString(m.header())
+	for _, s := range m.Sections {
+		b.WriteString(m.renderSection(s.Level, s.Title, s.Entries))
+	}
+	return b.String()
+}
+
+// Pages splits the menu in messages that fit in the limit, breaking on the
+// module boundaries. A module (or the list of files) bigger than a page is
+// broken between its entries. Each page leaves room for the Navigation footer.
+func (m *Menu) Pages(limit int) []string {
+	p := &pager{budget: limit - navigationReserve, current: m.intro(), fresh: true}
+
+	p.add(m.renderDocs, "Arquivos", m.Docs)
+	for _, s := range m.Sections {
+		render := func(title string, entries []Entry) string {
+			return m.renderSection(s.Level, title, entries)
+		}
+		p.add(render, s.Title, s.Entries)
+	}
+
+	return append(p.pages, p.current)
+}
+
+// pager fills the pages of the menu block by block
+type pager struct {
+	budget  int
+	pages   []string
+	current string
+	fresh   bool // The current page has only its header
+}
+
+func (p *pager) fits(block string) bool {
+	return textLength(p.current)+textLength(block) <= p.budget
+}
+
+func (p *pager) newPage() {
+	p.pages = append(p.pages, p.current)
+	p.current = continuationHeader
+	p.fresh = true
+}
+
+// add writes a block with its title and entries, on a new page when it doesn't
+// fit in the current one
+func (p *pager) add(render func(title string, entries []Entry) string, title string, entries []Entry) {
+	block := render(title, entries)
+	if !p.fits(block) && !p.fresh {
+		p.newPage()
+	}
+	if p.fits(block) {
+		p.current += block
+		p.fresh = false
+		return
+	}
+
+	// The block alone doesn't fit, so it's breaked in chunks of entries
+	chunkTitle := title
+	var chunk []Entry
+	for _, e := range entries {
+		if len(chunk) > 0 && !p.fits(render(chunkTitle, append(chunk, e))) {
+			p.current += render(chunkTitle, chunk)
+			p.newPage()
+			chunkTitle = title + " (cont.)"
+			chunk = nil
+		}
+		chunk = append(chunk, e)
+	}
+	p.current += render(chunkTitle, chunk)
+	p.fresh = false
+}
+
+// Max length of a Telegram text message
+const MaxMessageLength = 4096
+
+// Space kept in each page for the navigation footer
+const navigationReserve = 512
+
+const continuationHeader = "📑 <b>Menu do Curso (continuação)</b>\n"
+
+// Navigation is the footer of the page (0-based) cross-linking the other pages.
+// Without the links (not sent yet or basic groups) only the page number is shown.
+func Navigation(page int, links []string) string {
+	total := len(links)
+	if total <= 1 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("\n📄 <b>Página %d/%d</b>", page+1, total))
+
+	link := func(i int, label string) {
+		if links[i] == "" {
+			return
+		}
+		b.WriteString(fmt.Sprintf(" · <a href=\"%s\">%s</a>", html.EscapeString(links[i]), label))
+	}
+
+	if page > 1 {
+		link(0, "🏠 Início")
+	}
+	if page > 0 {
+		link(page-1, "◀️ Anterior")
+	}
+	if page < total-1 {
+		link(page+1, "Próxima ▶️")
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// header is the beginning of the menu: title, instructions and the files
+func (m *Menu) header() string {
+	return m.intro() + m.renderDocs("Arquivos", m.Docs)
+}
+
+// intro is the title of the menu and how to navigate it
+func (m *Menu) intro() string {
+	if m.Style == StyleHashtags {
+		return "⚠️ <b>Menu do Curso</b> ⚠️\n\nClique nas hashtags para navegar.\n\n"
+	}
+	return "⚠️ <b>Menu do Curso</b> ⚠️\n\nClique nos títulos para ir direto ao conteúdo.\n\n"
+}
+
+// renderDocs writes the list of files (support material)
+func (m *Menu) renderDocs(title string, docs []Entry) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📂 <b>%s</b>\n", title))
+	if len(docs) == 0 {
+		b.WriteString("<i>Nenhum material de apoio.</i>\n")
+	} else {
+		m.writeEntries(&b, docs)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// renderSection writes the title and the entries. The child modules are
+// indented under their parents, which may have only the title.
+func (m *Menu) renderSection(level int, title string, entries []Entry) string {
+	var b strings.Builder
+	if level == 0 {
+		b.WriteString(fmt.Sprintf("\n📁 <b>%s</b>\n", html.EscapeString(title)))
+	} else {
+		indent := strings.Repeat("   ", level-1)
+		b.WriteString(fmt.Sprintf("\n%s└ 📂 <b>%s</b>\n", indent, html.EscapeString(title)))
+	}
+	if len(entries) > 0 {
+		m.writeEntries(&b, entries)
+	}
+	return b.String()
+}
+
+func (m *Menu) writeEntries(b *strings.Builder, entries []Entry) {
+	if m.Style == StyleHashtags {
+		for _, e := range entries {
+			b.WriteString("#" + e.Tag + " ")
+		}
+		b.WriteString("\n")
+		return
+	}
+
+	// One entry per line, the hashtag is kept only when there's no link
+	for _, e := range entries {
+		title := html.EscapeString(e.Title)
+		if e.Link == "" {
+			b.WriteString(fmt.Sprintf("#%s %s\n", e.Tag, title))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("<a href=\"%s\">%s</a>\n", html.EscapeString(e.Link), title))
+	}
+}
+
+// textLength counts like Telegram does (UTF-16 code units). We count the raw HTML,
+// tags included, so the real message is always a bit shorter than the limit.
+func textLength(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
@@ -0,0 +1,148 @@
+package index
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// bigMenu has the files and one module with n entries each
+func bigMenu(style Style, docs, entries int) *Menu {
+	m := New(style)
+	for i := 1; i <= docs; i++ {
+		m.AddDoc(Entry{Tag: fmt.Sprintf("Doc%03d", i), Title: fmt.Sprintf("Apostila %d.pdf", i), Link: fmt.Sprintf("https://t.me/c/1/%d", i)})
+	}
+	s := m.AddSection("01 Módulo")
+	for i := 1; i <= entries; i++ {
+		s.Add(Entry{Tag: fmt.Sprintf("F%03d", i), Title: fmt.Sprintf("Aula %d", i), Link: fmt.Sprintf("https://t.me/c/1/%d", 1000+i)})
+	}
+	return m
+}
+
+// checkPages verifies that every page fits with the footer and that no entry was lost
+func checkPages(t *testing.T, pages []string, limit, entries int) {
+	t.Helper()
+	for i, page := range pages {
+		if n := textLength(page + Navigation(i, make([]string, len(pages)))); n > limit {
+			t.Errorf("página %d com %d caracteres, limite %d", i+1, n, limit)
+		}
+	}
+	all := strings.Join(pages, "")
+	for i := 1; i <= entries; i++ {
+		if !strings.Contains(all, fmt.Sprintf(">Aula %d<", i)) {
+			t.Errorf("aula %d perdida na paginação", i)
+		}
+	}
+}
+
+func TestPagesBoundary(t *testing.T) {
+	m := bigMenu(StyleLinks, 2, 5)
+	size := textLength(m.Render())
+
+	if pages := m.Pages(size + navigationReserve); len(pages) != 1 || pages[0] != m.Render() {
+		t.Errorf("menu do tamanho exato do limite em %d páginas", len(pages))
+	}
+	if pages := m.Pages(size + navigationReserve - 1); len(pages) != 2 {
+		t.Errorf("menu 1 caractere maior que o limite em %d páginas, esperado 2", len(pages))
+	}
+}
+
+func TestPagesSplitsBigModule(t *testing.T) {
+	m := bigMenu(StyleLinks, 1, 300)
+	pages := m.Pages(MaxMessageLength)
+
+	if len(pages) < 3 {
+		t.Fatalf("%d páginas, esperado o módulo dividido", len(pages))
+	}
+	checkPages(t, pages, MaxMessageLength, 300)
+	// The module starts on its own page, then continues on the next ones
+	for i, page := range pages[1:] {
+		cont := i > 0
+		if !strings.HasPrefix(page, continuationHeader) || strings.Contains(page, "01 Módulo (cont.)") != cont {
+			t.Errorf("página %d sem o cabeçalho de continuação:\n%s", i+2, page[:100])
+		}
+	}
+}
+
+func TestPagesSplitsBigFileList(t *testing.T) {
+	m := bigMenu(StyleLinks, 300, 3)
+	pages := m.Pages(MaxMessageLength)
+
+	if len(pages) < 3 {
+		t.Fatalf("%d páginas, esperado a lista de arquivos dividida", len(pages))
+	}
+	checkPages(t, pages, MaxMessageLength, 3)
+	if !strings.HasPrefix(pages[0], "⚠️ <b>Menu do Curso</b>") || !strings.Contains(pages[1], "📂 <b>Arquivos (cont.)</b>") {
+		t.Errorf("arquivos não continuaram na página seguinte")
+	}
+	for i := 1; i <= 300; i++ {
+		if !strings.Contains(strings.Join(pages, ""), fmt.Sprintf(">Apostila %d.pdf<", i)) {
+			t.Errorf("arquivo %d perdido na paginação", i)
+		}
+	}
+}
+
+func TestPagesHashtags(t *testing.T) {
+	m := bigMenu(StyleHashtags, 0, 1000)
+	pages := m.Pages(MaxMessageLength)
+
+	if len(pages) < 2 {
+		t.Fatalf("%d páginas, esperado as hashtags divididas", len(pages))
+	}
+	all := strings.Join(pages, "")
+	for i := 1; i <= 1000; i++ {
+		if !strings.Contains(all, fmt.Sprintf("#F%03d ", i)) {
+			t.Errorf("hashtag F%03d perdida na paginação", i)
+		}
+	}
+	for i, page := range pages {
+		if n := textLength(page); n > MaxMessageLength-navigationReserve {
+			t.Errorf("página %d com %d caracteres", i+1, n)
+		}
+	}
+}
+
+func TestRenderEntries(t *testing.T) {
+	m := New(StyleLinks)
+	s := m.AddSection("Módulo <1> & 2")
+	s.Add(Entry{Tag: "F001", Title: "Aula <a>", Link: "https://t.me/c/1/2?a=1&b=2"})
+	s.Add(Entry{Tag: "F002", Title: "Sem link"})
+
+	out := m.Render()
+	for _, want := range []string{
+		"📁 <b>Módulo &lt;1&gt; &amp; 2</b>",
+		`<a href="https://t.me/c/1/2?a=1&amp;b=2">Aula &lt;a&gt;</a>`,
+		"#F002 Sem link\n",
+		"<i>Nenhum material de apoio.</i>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("menu sem %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	links := []string{"https://t.me/c/1/1", "https://t.me/c/1/2", "https://t.me/c/1/3"}
+
+	cases := []struct {
+		page int
+		want string
+	}{
+		{0, "📄 <b>Página 1/3</b> · <a href=\"https://t.me/c/1/2\">Próxima ▶️</a>"},
+		{1, "📄 <b>Página 2/3</b> · <a href=\"https://t.me/c/1/1\">◀️ Anterior</a> · <a href=\"https://t.me/c/1/3\">Próxima ▶️</a>"},
+		{2, "📄 <b>Página 3/3</b> · <a href=\"https://t.me/c/1/1\">🏠 Início</a> · <a href=\"https://t.me/c/1/2\">◀️ Anterior</a>"},
+	}
+	for _, tc := range cases {
+		if got := strings.TrimSpace(Navigation(tc.page, links)); got != tc.want {
+			t.Errorf("página %d = %q, esperado %q", tc.page, got, tc.want)
+		}
+	}
+
+	// Not sent yet: only the number
+	if got := strings.TrimSpace(Navigation(1, make([]string, 3))); got != "📄 <b>Página 2/3</b>" {
+		t.Errorf("sem links = %q", got)
+	}
+	if got := Navigation(0, links[:1]); got != "" {
+		t.Errorf("página única com rodapé: %q", got)
+	}
+}
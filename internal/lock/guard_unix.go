@@ -0,0 +1,26 @@
+//go:build !windows
+
+package lock
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockGuard takes the guard of the takeovers of the lock file, waiting for the
+// process that has it. The kernel releases it if the process dies.
+func lockGuard(path string) (func(), error) {
+	file, err := os.OpenFile(path+guardSuffix, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Flock(int(file.Fd()), unix.LOCK_EX); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		unix.Flock(int(file.Fd()), unix.LOCK_UN)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,157 @@
+package lock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Info is written inside the lock file to tell who is holding it
+type Info struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	StartedAt time.Time `json:"started_at"`
+	Purpose   string    `json:"purpose"`
+}
+
+// LockedError is returned when another process holds the lock
+type LockedError struct {
+	Path string
+	Info Info
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s já está em uso pelo PID %d em %s desde %s (%s). "+
+		"Se tiver certeza que não há outro processo rodando, use --force-unlock",
+		e.Path, e.Info.PID, e.Info.Host, e.Info.StartedAt.Format("02/01/2006 15:04:05"), e.Info.Purpose)
+}
+
+// Lock is an exclusive lock file held by this process
+type Lock struct {
+	Path string
+}
+
+// Suffix of the file that serializes the takeovers of a lock, see lockGuard.
+// It stays next to the lock, removing it would let two takeovers run at once.
+const guardSuffix = ".guard"
+
+// Acquire creates the lock file exclusively. A lock left by a process that
+// died on this same host is considered stale and taken over. Locks from other
+// hosts can't be verified, so they are only taken with force.
+func Acquire(path, purpose string, force bool) (*Lock, error) {
+	host, _ := os.Hostname()
+	info := Info{
+		PID:       os.Getpid(),
+		Host:      host,
+		StartedAt: time.Now(),
+		Purpose:   purpose,
+	}
+
+	content, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// The first attempt may find a stale (or forced) lock, the next ones run
+	// after it was removed, or after it changed hands while we were deciding
+	for attempt := 0; attempt < 3; attempt++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, werr := file.Write(content)
+			cerr := file.Close()
+			if werr != nil || cerr != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("erro ao escrever lock %s: %w", path, errors.Join(werr, cerr))
+			}
+			return &Lock{Path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("erro ao criar lock %s: %w", path, err)
+		}
+
+		seen, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue // Released meanwhile
+		}
+
+		var holder Info
+		readErr := errors.Join(err, json.Unmarshal(seen, &holder))
+		switch {
+		case force:
+			fmt.Printf("🔓 Removendo lock à força: %s\n", path)
+		case readErr != nil:
+			// Probably a crash while the lock was being written
+			fmt.Printf("🔓 Lock ilegível, considerado abandonado: %s\n", path)
+		case holder.Host == host && !processAlive(holder.PID):
+			fmt.Printf("🔓 Lock abandonado pelo PID %d (processo não existe mais): %s\n", holder.PID, path)
+		default:
+			return nil, &LockedError{Path: path, Info: holder}
+		}
+
+		if err := removeIfUnchanged(path, seen); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("não foi possível obter o lock %s", path)
+}
+
+// removeIfUnchanged removes the lock judged stale, unless another process took
+// it over since it was read. Two processes finding the same stale lock would
+// otherwise both remove it, the second one removing the new lock of the first.
+func removeIfUnchanged(path string, seen []byte) error {
+	release, err := lockGuard(path)
+	if err != nil {
+		return fmt.Errorf("erro ao remover lock %s: %w", path, err)
+	}
+	defer release()
+
+	current, err := os.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && !bytes.Equal(current, seen)) {
+		return nil // Someone else got there first, look at the lock again
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("erro ao remover lock %s: %w", path, err)
+	}
+	return nil
+}
+
+// Release removes the lock file, only if it still belongs to this process
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+
+	holder, err := readInfo(l.Path)
+	if err != nil {
+		return nil
+	}
+	if holder.PID != os.Getpid() {
+		return nil
+	}
+	return os.Remove(l.Path)
+}
+
+// InUse reports if the lock file exists and its process is still running.
+// Locks of other hosts can't be verified, so they count as in use.
+func InUse(path string) bool {
+	holder, err := readInfo(path)
+	if err != nil {
+		return false
+	}
+	host, _ := os.Hostname()
+	return holder.Host != host || processAlive(holder.PID)
+}
+
+func readInfo(path string) (Info, error) {
+	var info Info
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return info, err
+	}
+	err = json.Unmarshal(content, &info)
+	return info, err
+}
@@ -0,0 +1,132 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeHolder creates a lock file as if another process held it
+func writeHolder(t *testing.T, path string, info Info) {
+	t.Helper()
+	content, _ := json.Marshal(info)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// deadPID is a PID that no process has
+const deadPID = 2147483000
+
+func TestAcquireContention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "curso.lock")
+
+	l, err := Acquire(path, "primeiro", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var locked *LockedError
+	if _, err := Acquire(path, "segundo", false); !errors.As(err, &locked) {
+		t.Fatalf("segundo Acquire = %v, esperado LockedError", err)
+	}
+	if locked.Info.PID != os.Getpid() || locked.Info.Purpose != "primeiro" {
+		t.Errorf("dono do lock = %+v", locked.Info)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	l, err = Acquire(path, "segundo", false)
+	if err != nil {
+		t.Fatalf("Acquire depois do Release: %v", err)
+	}
+	l.Release()
+}
+
+func TestAcquireTakesOverStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "curso.lock")
+	host, _ := os.Hostname()
+	writeHolder(t, path, Info{PID: deadPID, Host: host, StartedAt: time.Now()})
+
+	l, err := Acquire(path, "novo", false)
+	if err != nil {
+		t.Fatalf("lock abandonado não foi tomado: %v", err)
+	}
+	defer l.Release()
+
+	if holder, _ := readInfo(path); holder.PID != os.Getpid() {
+		t.Errorf("dono do lock = %d, esperado %d", holder.PID, os.Getpid())
+	}
+}
+
+func TestAcquireStaleLockOnlyOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "curso.lock")
+	host, _ := os.Hostname()
+	writeHolder(t, path, Info{PID: deadPID, Host: host, StartedAt: time.Now()})
+
+	// Every goroutine finds the same stale lock, only one can get it
+	const workers = 8
+	var wg sync.WaitGroup
+	results := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Acquire(path, "concorrente", false)
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	acquired := 0
+	for err := range results {
+		var locked *LockedError
+		switch {
+		case err == nil:
+			acquired++
+		case !errors.As(err, &locked):
+			t.Errorf("erro inesperado: %v", err)
+		}
+	}
+	if acquired != 1 {
+		t.Errorf("%d processos obtiveram o lock, esperado 1", acquired)
+	}
+}
+
+func TestAcquireOtherHostNeedsForce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "curso.lock")
+	writeHolder(t, path, Info{PID: deadPID, Host: "outra-maquina", StartedAt: time.Now()})
+
+	var locked *LockedError
+	if _, err := Acquire(path, "novo", false); !errors.As(err, &locked) {
+		t.Fatalf("lock de outra máquina tomado sem force: %v", err)
+	}
+
+	l, err := Acquire(path, "novo", true)
+	if err != nil {
+		t.Fatalf("Acquire com force: %v", err)
+	}
+	defer l.Release()
+	if holder, _ := readInfo(path); holder.PID != os.Getpid() {
+		t.Errorf("dono do lock = %+v", holder)
+	}
+}
+
+func TestAcquireUnreadableLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "curso.lock")
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Acquire(path, "novo", false)
+	if err != nil {
+		t.Fatalf("lock ilegível não foi tomado: %v", err)
+	}
+	l.Release()
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package lock
+
+import "os"
+
+// On Windows FindProcess opens a handle to the process, failing if it doesn't exist
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,361 @@
+// Package plan describes what an upload of the course would do, without
+// connecting to Telegram or writing any file.
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/FolcloreX/GopherGram/internal/domain"
+	"github.com/FolcloreX/GopherGram/internal/processor"
+	"github.com/FolcloreX/GopherGram/internal/state"
+)
+
+// Status of a video in the state
+const (
+	StatusPending = "pending"
+	StatusPartial = "partial"
+	StatusDone    = "done"
+)
+
+// Name of the zip created with the assets
+const ZipName = "Arquivos.zip"
+
+type Plan struct {
+	Course   string     `json:"course"` // Name of the folder, identifies the state
+	Title    string     `json:"title"`
+	Author   string     `json:"author,omitempty"`
+	Year     int        `json:"year,omitempty"`
+	Cover    string     `json:"cover,omitempty"`
+	RootPath string     `json:"root_path"`
+	State    *StateInfo `json:"state,omitempty"` // Nil when nothing was uploaded yet
+	Modules  []Module   `json:"modules"`
+	Assets   Assets     `json:"assets"`
+	Ignored  []Ignored  `json:"ignored"` // Left out by .gopherignore, course.yaml, --include/--exclude or the defaults
+	Totals   Totals     `json:"totals"`
+}
+
+type StateInfo struct {
+	Location string `json:"location"`
+	ChatID   int64  `json:"chat_id"`
+	Files    int    `json:"files"`
+}
+
+type Module struct {
+	Name   string  `json:"name"`   // With the names of the parents. Ex: Seção 1 › Boas-vindas
+	Folder string  `json:"folder"` // Relative to the course folder
+	Depth  int     `json:"depth"`  // 1 for the folders in the root
+	Videos []Video `json:"videos"`
+}
+
+type Video struct {
+	ID       string `json:"id"`
+	Kind     string `json:"kind"` // "video" or "audio"
+	Sequence int    `json:"sequence"`
+	Title    string `json:"title"`
+	Path     string `json:"path"` // Relative to the course folder
+	Size     int64  `json:"size"`
+	Duration int    `json:"duration"` // Seconds, 0 if unknown
+	Parts    int    `json:"parts"`
+	Uploaded int    `json:"uploaded_parts"`
+	Status   string `json:"status"`
+
+	Subtitles []string `json:"subtitles,omitempty"` // Relative to the course folder
+}
+
+type Assets struct {
+	Files    []Asset `json:"files"`
+	Size     int64   `json:"size"`
+	Zip      string  `json:"zip,omitempty"`
+	ZipParts int     `json:"zip_parts"` // Estimated, the zip is compressed
+	Uploaded int     `json:"uploaded_parts"`
+}
+
+type Asset struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+type Ignored struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+type Totals struct {
+	Modules         int   `json:"modules"`
+	Videos          int   `json:"videos"`
+	Assets          int   `json:"assets"`
+	Ignored         int   `json:"ignored"`
+	Size            int64 `json:"size"`
+	Duration        int   `json:"duration"`
+	UnknownDuration int   `json:"unknown_duration"` // Videos that ffprobe couldn't read
+	Splitted        int   `json:"splitted"`         // Videos splitted in parts
+	Uploads         int   `json:"uploads"`          // Files to upload (video parts + zip parts)
+	Done            int   `json:"done"`             // Files already uploaded
+}
+
+type Options struct {
+	MaxFileSize int64
+
+	// Duration in seconds of the video, nil skips it
+	Probe func(path string) (int, error)
+}
+
+// Build creates the plan of the course. snap is the state of the previous runs,
+// nil if there's none.
+func Build(course *domain.Course, snap *state.Snapshot, opts Options) (*Plan, error) {
+	if opts.MaxFileSize == 0 {
+		opts.MaxFileSize = domain.MaxFileSize
+	}
+
+	p := &Plan{
+		Course:   filepath.Base(course.RootPath),
+		Title:    course.Title,
+		Author:   course.Author,
+		Year:     course.Year,
+		Cover:    course.CoverPath,
+		RootPath: course.RootPath,
+		Modules:  []Module{},
+		Ignored:  []Ignored{},
+	}
+
+	if p.Title == "" {
+		p.Title = p.Course
+	}
+
+	for _, ig := range course.Ignored {
+		p.Ignored = append(p.Ignored, Ignored{Path: relPath(course.RootPath, ig.Path), Reason: ig.Reason})
+	}
+	p.Totals.Ignored = len(p.Ignored)
+
+	if snap != nil {
+		p.State = &StateInfo{Location: snap.Location, ChatID: snap.ChatID, Files: len(snap.Records)}
+	}
+
+	for _, mod := range course.AllModules() {
+		m := Module{Name: mod.FullName(), Folder: mod.Folder, Depth: mod.Depth(), Videos: []Video{}}
+
+		for _, v := range mod.Videos {
+			video, err := buildVideo(course.RootPath, v, snap, opts)
+			if err != nil {
+				return nil, err
+			}
+			m.Videos = append(m.Videos, video)
+
+			p.Totals.Videos++
+			p.Totals.Size += video.Size
+			p.Totals.Duration += video.Duration
+			p.Totals.Uploads += video.Parts
+			p.Totals.Done += video.Uploaded
+			if video.Parts > 1 {
+				p.Totals.Splitted++
+			}
+			if video.Duration == 0 {
+				p.Totals.UnknownDuration++
+			}
+		}
+
+		p.Modules = append(p.Modules, m)
+	}
+	p.Totals.Modules = len(p.Modules)
+
+	p.Assets = buildAssets(course, snap, opts)
+	p.Totals.Assets = len(p.Assets.Files)
+	p.Totals.Size += p.Assets.Size
+	p.Totals.Uploads += p.Assets.ZipParts
+	p.Totals.Done += min(p.Assets.Uploaded, p.Assets.ZipParts)
+
+	return p, nil
+}
+
+func buildVideo(root string, v *domain.Video, snap *state.Snapshot, opts Options) (Video, error) {
+	video := Video{
+		ID:       v.ID,
+		Kind:     string(v.Kind),
+		Sequence: v.Sequence,
+		Title:    v.CleanTitle(),
+		Path:     relPath(root, v.FilePath),
+		Size:     v.Size,
+		Parts:    processor.EstimateVideoParts(v.Size, opts.MaxFileSize),
+		Status:   StatusPending,
+	}
+	// The audios are never splitted
+	if v.IsAudio() {
+		video.Parts = 1
+	}
+	for _, sub := range v.Subtitles {
+		video.Subtitles = append(video.Subtitles, relPath(root, sub.Path))
+	}
+
+	if opts.Probe != nil {
+		if seconds, err := opts.Probe(v.FilePath); err == nil {
+			video.Duration = seconds
+		}
+	}
+
+	if snap == nil {
+		return video, nil
+	}
+
+	if snap.LegacyPaths[v.FilePath] {
+		video.Uploaded = video.Parts
+		video.Status = StatusDone
+		return video, nil
+	}
+
+	fingerprint, err := state.Fingerprint(v.FilePath)
+	if err != nil {
+		return video, fmt.Errorf("erro ao ler %s: %w", v.FileName, err)
+	}
+
+	done, total := snap.Parts(fingerprint)
+	if total > 0 {
+		// Trust the real split of the previous run over the estimate
+		video.Parts = total
+	}
+	video.Uploaded = done
+
+	switch {
+	case done == 0:
+		video.Status = StatusPending
+	case done < video.Parts:
+		video.Status = StatusPartial
+	default:
+		video.Status = StatusDone
+	}
+	return video, nil
+}
+
+func buildAssets(course *domain.Course, snap *state.Snapshot, opts Options) Assets {
+	assets := Assets{Files: []Asset{}}
+
+	for _, path := range course.Assets {
+		var size int64
+		if info, err := os.Stat(path); err == nil {
+			size = info.Size()
+		}
+		assets.Files = append(assets.Files, Asset{Path: relPath(course.RootPath, path), Size: size})
+		assets.Size += size
+	}
+
+	if len(assets.Files) == 0 {
+		return assets
+	}
+
+	assets.Zip = ZipName
+	assets.ZipParts = 1
+	if assets.Size > opts.MaxFileSize {
+		assets.ZipParts = int((assets.Size + opts.MaxFileSize - 1) / opts.MaxFileSize)
+	}
+
+	// The zip is recreated on every run, its parts are found by the caption
+	if snap != nil {
+		for _, rec := range snap.Records {
+			if strings.HasPrefix(rec.Caption, "#"+domain.HashTagDoc) {
+				assets.Uploaded++
+			}
+		}
+	}
+	return assets
+}
+
+func relPath(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// WriteJSON writes the plan as indented JSON
+func (p *Plan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// WriteTable writes the plan as a human readable table
+func (p *Plan) WriteTable(w io.Writer) error {
+	fmt.Fprintf(w, "\n📋 PLANO: %s\n📂 Pasta: %s\n", p.Title, p.RootPath)
+	if p.Cover != "" {
+		fmt.Fprintf(w, "🖼 Capa: %s\n", p.Cover)
+	}
+	if p.State != nil {
+		fmt.Fprintf(w, "💾 Estado: %s (chat %d, %d arquivo(s) enviados)\n", p.State.Location, p.State.ChatID, p.State.Files)
+	} else {
+		fmt.Fprintln(w, "💾 Estado: nenhum upload anterior")
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	for _, m := range p.Modules {
+		fmt.Fprintf(tw, "\n🔹 %s\n", m.Name)
+		// The parents may have only the subfolders
+		if len(m.Videos) == 0 {
+			continue
+		}
+		fmt.Fprintln(tw, "ID\t#\tVÍDEO\tTAMANHO\tDURAÇÃO\tPARTES\tESTADO\t")
+		for _, v := range m.Videos {
+			title := v.Title
+			if v.Kind == string(domain.MediaAudio) {
+				title = "🎧 " + title
+			}
+			if len(v.Subtitles) > 0 {
+				title += fmt.Sprintf(" 💬%d", len(v.Subtitles))
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%d\t%s\t\n",
+				v.ID, v.Sequence, title, processor.FormatSize(v.Size),
+				formatDuration(v.Duration), v.Parts, formatStatus(v))
+		}
+	}
+
+	if len(p.Assets.Files) > 0 {
+		fmt.Fprintf(tw, "\n🗂 Material de Apoio -> %s (%d parte(s), %d enviada(s))\n",
+			p.Assets.Zip, p.Assets.ZipParts, p.Assets.Uploaded)
+		for _, a := range p.Assets.Files {
+			fmt.Fprintf(tw, "%s\t%s\t\n", a.Path, processor.FormatSize(a.Size))
+		}
+	}
+
+	if len(p.Ignored) > 0 {
+		fmt.Fprintf(tw, "\n🚫 Ignorados (%d)\n", len(p.Ignored))
+		for _, ig := range p.Ignored {
+			fmt.Fprintf(tw, "%s\t%s\t\n", ig.Path, ig.Reason)
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	t := p.Totals
+	fmt.Fprintf(w, "\n📊 Total: %d Módulos | %d Vídeos (%d divididos) | %d Assets | %d Ignorados | %s | %s",
+		t.Modules, t.Videos, t.Splitted, t.Assets, t.Ignored, processor.FormatSize(t.Size), formatDuration(t.Duration))
+	if t.UnknownDuration > 0 {
+		fmt.Fprintf(w, " (+%d sem duração)", t.UnknownDuration)
+	}
+	fmt.Fprintf(w, "\n📤 Envios: %d de %d arquivo(s) já enviados, %d pendentes\n", t.Done, t.Uploads, t.Uploads-t.Done)
+	return nil
+}
+
+func formatDuration(seconds int) string {
+	if seconds == 0 {
+		return "?"
+	}
+	return fmt.Sprintf("%dh %02dm %02ds", seconds/3600, (seconds%3600)/60, seconds%60)
+}
+
+func formatStatus(v Video) string {
+	switch v.Status {
+	case StatusDone:
+		return "✅ enviado"
+	case StatusPartial:
+		return fmt.Sprintf("⏳ %d/%d", v.Uploaded, v.Parts)
+	default:
+		return "⬜ pendente"
+	}
+}
@@ -0,0 +1,60 @@
+package processor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// AudioMeta is what Telegram shows in the audio player
+type AudioMeta struct {
+	Duration  int
+	Title     string
+	Performer string
+	CoverPath string // Embedded cover art, empty if the file has none
+}
+
+// ExtractAudioMeta reads the duration and the tags of the audio through ffprobe,
+// and saves the cover art (if any) in coverDir as the thumbnail.
+func ExtractAudioMeta(audioPath, coverDir string) (*AudioMeta, error) {
+	probe, err := ProbeFile(audioPath)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &AudioMeta{
+		Duration:  int(probe.Duration()),
+		Title:     probe.Tag("title"),
+		Performer: probe.Tag("artist", "album_artist"),
+	}
+
+	// The cover art is a video stream with a single picture
+	for _, stream := range probe.Streams {
+		if stream.CodecType != "video" {
+			continue
+		}
+
+		name := filepath.Base(audioPath)
+		coverPath := filepath.Join(coverDir, strings.TrimSuffix(name, filepath.Ext(name))+"_thumb.jpg")
+		if err := runFFmpeg("-y", "-i", audioPath, "-map", "0:v:0", "-frames:v", "1", "-vf", "scale=320:-1", coverPath); err != nil {
+			fmt.Printf("⚠️ Aviso: Não foi possível extrair a capa: %v\n", err)
+		} else {
+			meta.CoverPath = coverPath
+		}
+		break
+	}
+
+	return meta, nil
+}
+
+// tag is the first of the keys found in the tags. MP3 uses "title", Vorbis/FLAC "TITLE"
+func tag(tags map[string]string, keys ...string) string {
+	for _, key := range keys {
+		for k, v := range tags {
+			if strings.EqualFold(k, key) && strings.TrimSpace(v) != "" {
+				return strings.TrimSpace(v)
+			}
+		}
+	}
+	return ""
+}
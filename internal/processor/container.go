@@ -0,0 +1,124 @@
+package processor
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ContainerInfo is the container and the codecs of a video, read by ffprobe
+type ContainerInfo struct {
+	Format      string // format_name of ffprobe. Ex: "mov,mp4,m4a,3gp,3g2,mj2" or "matroska,webm"
+	Brand       string // major_brand of the mp4 family. "qt  " is a .mov
+	VideoCodec  string
+	AudioCodecs []string // One per audio stream, the remux copies all of them. Empty if the video has no audio
+}
+
+// Codecs that every Telegram client plays inside an MP4. HEVC only plays on
+// some devices, so it's transcoded too.
+var (
+	streamableVideo = []string{"h264"}
+	streamableAudio = []string{"aac", "mp3"}
+)
+
+// ProbeContainer reads the container, the codec of the video and the codecs of the audio streams
+func ProbeContainer(path string) (*ContainerInfo, error) {
+	probe, err := ProbeFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return probe.Container(), nil
+}
+
+// Container is the container, the codec of the video and the codecs of every audio stream
+func (p *Probe) Container() *ContainerInfo {
+	info := &ContainerInfo{Format: p.Format.FormatName, Brand: p.Tag("major_brand")}
+	if video := p.Video(); video != nil {
+		info.VideoCodec = video.CodecName
+	}
+	for _, audio := range p.AudioTracks() {
+		info.AudioCodecs = append(info.AudioCodecs, audio.CodecName)
+	}
+	return info
+}
+
+// Codecs describes the streams for the messages. Ex: "h264/aac+ac3"
+func (c *ContainerInfo) Codecs() string {
+	audio := strings.Join(c.AudioCodecs, "+")
+	if audio == "" {
+		audio = "sem áudio"
+	}
+	return c.VideoCodec + "/" + audio
+}
+
+// IsMP4 reports if the container is an MP4 (a .mov has the same format_name, but other brand)
+func (c *ContainerInfo) IsMP4() bool {
+	return strings.Contains(c.Format, "mp4") && strings.TrimSpace(c.Brand) != "qt"
+}
+
+// Streamable reports if Telegram can play the video while it's downloaded
+func (c *ContainerInfo) Streamable() bool {
+	return c.IsMP4() && c.CanRemux()
+}
+
+// CanRemux reports if the streams can be copied into an MP4 without re-encoding.
+// Every audio stream is copied, so all of them must be playable.
+func (c *ContainerInfo) CanRemux() bool {
+	if !slices.Contains(streamableVideo, c.VideoCodec) {
+		return false
+	}
+	for _, codec := range c.AudioCodecs {
+		if !slices.Contains(streamableAudio, codec) {
+			return false
+		}
+	}
+	return true
+}
+
+// RemuxToMP4 copies the video and audio streams into an MP4 with the index at
+// the start (+faststart). Fast and lossless, only works when CanRemux.
+func RemuxToMP4(videoPath, outPath string) error {
+	return runFFmpeg(
+		"-y",
+		"-i", videoPath,
+		"-map", "0:v:0",
+		"-map", "0:a?",
+		"-c", "copy",
+		"-movflags", "+faststart",
+		outPath,
+	)
+}
+
+// TranscodeToMP4 re-encodes the video as H.264/AAC. Slow, but plays everywhere.
+func TranscodeToMP4(videoPath, outPath string) error {
+	return runFFmpeg(
+		"-y",
+		"-i", videoPath,
+		"-map", "0:v:0",
+		"-map", "0:a?",
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-crf", "20",
+		"-pix_fmt", "yuv420p",
+		"-c:a", "aac",
+		"-b:a", "192k",
+		"-movflags", "+faststart",
+		outPath,
+	)
+}
+
+// VideoMimeType is the type of the video formats accepted by domain.IsVideo
+func VideoMimeType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mkv":
+		return "video/x-matroska"
+	case ".avi":
+		return "video/x-msvideo"
+	case ".mov":
+		return "video/quicktime"
+	case ".webm":
+		return "video/webm"
+	default:
+		return "video/mp4"
+	}
+}
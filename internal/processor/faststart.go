@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// NeedsFaststart reports if the moov atom (the index of the MP4) comes after
+// the mdat (the media). The player must download the whole file to find it,
+// even with SupportsStreaming.
+func NeedsFaststart(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	// Walk the top level atoms: 4 bytes of size + 4 of type, size 1 means a 64 bits size after the type
+	var offset int64
+	header := make([]byte, 16)
+	for offset+8 <= info.Size() {
+		if _, err := f.ReadAt(header[:8], offset); err != nil {
+			return false, fmt.Errorf("erro ao ler átomo em %d: %w", offset, err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		atom := string(header[4:8])
+
+		switch size {
+		case 0:
+			// Goes until the end of the file
+			size = info.Size() - offset
+		case 1:
+			if _, err := f.ReadAt(header[8:16], offset+8); err != nil && err != io.EOF {
+				return false, fmt.Errorf("erro ao ler átomo em %d: %w", offset, err)
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+		}
+		if size < 8 {
+			return false, fmt.Errorf("átomo %q com tamanho inválido em %d", atom, offset)
+		}
+
+		switch atom {
+		case "moov":
+			return false, nil
+		case "mdat":
+			return true, nil
+		}
+		offset += size
+	}
+	return false, nil
+}
+
+// Faststart copies the MP4 moving the moov atom to the start. Lossless, the streams are copied.
+func Faststart(videoPath, outPath string) error {
+	return runFFmpeg(
+		"-y",
+		"-i", videoPath,
+		"-map", "0",
+		"-c", "copy",
+		"-movflags", "+faststart",
+		outPath,
+	)
+}
@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// atom builds an MP4 atom with the payload filled with zeros
+func atom(kind string, payload int) []byte {
+	buf := make([]byte, 8+payload)
+	binary.BigEndian.PutUint32(buf, uint32(len(buf)))
+	copy(buf[4:], kind)
+	return buf
+}
+
+// largeAtom uses the 64 bits size, like the mdat of the videos over 4GB
+func largeAtom(kind string, payload int) []byte {
+	buf := make([]byte, 16+payload)
+	binary.BigEndian.PutUint32(buf, 1)
+	copy(buf[4:], kind)
+	binary.BigEndian.PutUint64(buf[8:], uint64(len(buf)))
+	return buf
+}
+
+func TestNeedsFaststart(t *testing.T) {
+	cases := []struct {
+		name  string
+		atoms [][]byte
+		want  bool
+	}{
+		{"moov no início", [][]byte{atom("ftyp", 16), atom("moov", 32), atom("mdat", 64)}, false},
+		{"moov no fim", [][]byte{atom("ftyp", 16), atom("free", 8), atom("mdat", 64), atom("moov", 32)}, true},
+		{"mdat de 64 bits", [][]byte{atom("ftyp", 16), largeAtom("mdat", 64), atom("moov", 32)}, true},
+		{"sem moov", [][]byte{atom("ftyp", 16)}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var data []byte
+			for _, a := range tc.atoms {
+				data = append(data, a...)
+			}
+			path := filepath.Join(t.TempDir(), "aula.mp4")
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := NeedsFaststart(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("NeedsFaststart = %v, esperado %v", got, tc.want)
+			}
+		})
+	}
+
+	// Not an MP4: the sizes don't make sense
+	path := filepath.Join(t.TempDir(), "texto.mp4")
+	if err := os.WriteFile(path, []byte("\x00\x00\x00\x02isso não é um mp4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NeedsFaststart(path); err == nil {
+		t.Error("esperado erro para um arquivo que não é MP4")
+	}
+}
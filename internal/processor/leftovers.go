@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/FolcloreX/GopherGram/internal/domain"
+)
+
+// Ex: aula-part-002.mp4, the exact name SplitVideo wrote next to the video
+// before the run folder. aula-part-2.mp4 may be a file of the author.
+var splitPartRe = regexp.MustCompile(`^(.+)-part-\d{3}(\.[^.]+)$`)
+
+// The thumbnails and covers were written with ffmpeg -vf scale=320:-1
+const thumbWidth = 320
+
+// FindLeftovers lists the split parts and thumbnails left in the course folder by
+// runs that crashed. A file is only considered a leftover if its original video
+// is still next to it, so user files with similar names are never touched.
+func FindLeftovers(root string) ([]string, error) {
+	var leftovers []string
+
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		if IsLeftover(path) {
+			leftovers = append(leftovers, path)
+		}
+		return nil
+	})
+
+	return leftovers, err
+}
+
+// IsLeftover reports if the file is a split part or a thumbnail generated by us,
+// checking that its original video is still next to it and, for the thumbnails,
+// that it has the width we generated.
+func IsLeftover(path string) bool {
+	dir, name := filepath.Split(path)
+
+	if m := splitPartRe.FindStringSubmatch(name); m != nil && domain.IsVideo(name) {
+		return fileExists(filepath.Join(dir, m[1]+m[2]))
+	}
+
+	if base, ok := strings.CutSuffix(name, "_thumb.jpg"); ok && isGeneratedThumb(path) {
+		// The thumb can belong to the video itself, to one of its parts or be the cover of an audio
+		siblings, _ := os.ReadDir(dir)
+		for _, s := range siblings {
+			sibling := s.Name()
+			if domain.IsMedia(sibling) && strings.TrimSuffix(sibling, filepath.Ext(sibling)) == base {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isGeneratedThumb reports if the file is a JPEG as wide as the thumbnails of ffmpeg
+func isGeneratedThumb(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	cfg, err := jpeg.DecodeConfig(f)
+	return err == nil && cfg.Width == thumbWidth
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeJPEG(t *testing.T, path string, width, height int) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := jpeg.Encode(f, image.NewRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsLeftover(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"Aula 1.mp4", "Aula 1-part-002.mp4", "Aula 5.mp4", "Aula 5-part-2.mp4", "Sem original-part-001.mp4", "Podcast.mp3"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeJPEG(t, filepath.Join(dir, "Aula 1_thumb.jpg"), 320, 180)
+	writeJPEG(t, filepath.Join(dir, "Podcast_thumb.jpg"), 320, 320)
+	writeJPEG(t, filepath.Join(dir, "Aula 5_thumb.jpg"), 1280, 720)
+	writeJPEG(t, filepath.Join(dir, "Sem video_thumb.jpg"), 320, 180)
+
+	cases := map[string]bool{
+		"Aula 1-part-002.mp4":       true,
+		"Aula 1_thumb.jpg":          true,
+		"Podcast_thumb.jpg":         true,
+		"Aula 1.mp4":                false,
+		"Aula 5-part-2.mp4":         false, // Not the name SplitVideo wrote
+		"Aula 5_thumb.jpg":          false, // Not as wide as our thumbnails
+		"Sem original-part-001.mp4": false,
+		"Sem video_thumb.jpg":       false,
+	}
+	for name, want := range cases {
+		if got := IsLeftover(filepath.Join(dir, name)); got != want {
+			t.Errorf("IsLeftover(%s) = %v, esperado %v", name, got, want)
+		}
+	}
+}
@@ -1,11 +1,9 @@
 package processor
 
 import (
-	"bytes"
 	"fmt"
 	"os/exec"
 	"path/filepath"
-	"strconv"
 	"strings"
 )
 
@@ -14,42 +12,31 @@ type VideoMeta struct {
 	Height    int
 	Duration  int
 	ThumbPath string
-}
 
-func ExtractMetadata(videoPath string) (*VideoMeta, error) {
-	// Gets the Widht, Height and Duration through ffprobe
-	// Command: 
-	// ffprobe 
-	// -v error
-	// -select_streams v:0 
-	// -show_entries 
-	// stream=width,height,duration -of csv=s=x:p=0 input.mp4
-	cmd := exec.Command("ffprobe",
-		"-v", "error",
-		"-select_streams", "v:0",
-		"-show_entries", "stream=width,height,duration",
-		"-of", "csv=s=x:p=0",
-		videoPath,
-	)
+	// Set by the caller, ExtractMetadata only reads the streams
+	MimeType   string
+	Streamable bool // Telegram can play it while downloading (MP4 H.264/AAC)
+}
 
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("erro no ffprobe: %w", err)
+// ExtractMetadata reads the size (as displayed) and the duration of the video
+// and saves a thumbnail of it in thumbDir, so nothing is written next to the video.
+func ExtractMetadata(videoPath, thumbDir string) (*VideoMeta, error) {
+	probe, err := ProbeFile(videoPath)
+	if err != nil {
+		return nil, err
 	}
-
-	// Output example: "1920x1080x300.5"w
-	parts := strings.Split(strings.TrimSpace(out.String()), "x")
-	if len(parts) < 3 {
-		return nil, fmt.Errorf("dados do ffprobe inválidos: %s", out.String())
+	video := probe.Video()
+	if video == nil {
+		return nil, fmt.Errorf("nenhuma faixa de vídeo em %s", filepath.Base(videoPath))
 	}
 
-	width, _ := strconv.Atoi(parts[0])
-	height, _ := strconv.Atoi(parts[1])
-	durFloat, _ := strconv.ParseFloat(parts[2], 64)
+	// Phone videos are recorded turned, Telegram wants the size as it's shown
+	width, height := video.DisplaySize()
+	duration := probe.Duration()
 
 	// Capture the frame in the first second of video to use as thumbnail
-	thumbPath := strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + "_thumb.jpg"
+	name := filepath.Base(videoPath)
+	thumbPath := filepath.Join(thumbDir, strings.TrimSuffix(name, filepath.Ext(name))+"_thumb.jpg")
 
 	// Command: ffmpeg 
 	// -i input.mp4 
@@ -75,7 +62,7 @@ func ExtractMetadata(videoPath string) (*VideoMeta, error) {
 	return &VideoMeta{
 		Width:     width,
 		Height:    height,
-		Duration:  int(durFloat),
+		Duration:  int(duration),
 		ThumbPath: thumbPath,
 	}, nil
 }
@@ -0,0 +1,241 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Probe is what ffprobe knows about a media file: the container, every stream
+// and the chapters. Read once with ProbeFile and shared by the steps that need it.
+type Probe struct {
+	Format   ProbeFormat    `json:"format"`
+	Streams  []ProbeStream  `json:"streams"`
+	Chapters []ProbeChapter `json:"chapters"`
+}
+
+type ProbeFormat struct {
+	FormatName string            `json:"format_name"` // Ex: "mov,mp4,m4a,3gp,3g2,mj2" or "matroska,webm"
+	Duration   string            `json:"duration"`    // Seconds, "N/A" or empty when unknown
+	Size       string            `json:"size"`
+	BitRate    string            `json:"bit_rate"`
+	Tags       map[string]string `json:"tags"`
+}
+
+type ProbeStream struct {
+	Index             int               `json:"index"`
+	CodecType         string            `json:"codec_type"` // video, audio, subtitle, attachment...
+	CodecName         string            `json:"codec_name"`
+	Profile           string            `json:"profile"`
+	Width             int               `json:"width"`
+	Height            int               `json:"height"`
+	SampleAspectRatio string            `json:"sample_aspect_ratio"` // Ex: "1:1", "4:3". "N/A" or "0:1" when unknown
+	Duration          string            `json:"duration"`            // Missing in the MKVs, see the DURATION tag
+	BitRate           string            `json:"bit_rate"`
+	Channels          int               `json:"channels"`
+	SampleRate        string            `json:"sample_rate"`
+	Disposition       map[string]int    `json:"disposition"`
+	Tags              map[string]string `json:"tags"`
+	SideData          []struct {
+		Type     string  `json:"side_data_type"`
+		Rotation float64 `json:"rotation"`
+	} `json:"side_data_list"`
+}
+
+type ProbeChapter struct {
+	StartTime string            `json:"start_time"`
+	EndTime   string            `json:"end_time"`
+	Tags      map[string]string `json:"tags"`
+}
+
+// ProbeFile runs ffprobe on the file
+func ProbeFile(path string) (*Probe, error) {
+	// Command: ffprobe -v error -show_format -show_streams -show_chapters -of json input.mp4
+	cmd := exec.Command("ffprobe",
+		"-v", "error",
+		"-show_format",
+		"-show_streams",
+		"-show_chapters",
+		"-of", "json",
+		path,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("erro no ffprobe: %w", err)
+	}
+	return parseProbe(out)
+}
+
+func parseProbe(data []byte) (*Probe, error) {
+	var p Probe
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("dados do ffprobe inválidos: %w", err)
+	}
+	return &p, nil
+}
+
+// Duration is the duration in seconds of the file. When the container doesn't
+// have it, it's the longest of the streams. 0 if unknown.
+func (p *Probe) Duration() float64 {
+	if d := parseSeconds(p.Format.Duration); d > 0 {
+		return d
+	}
+	var longest float64
+	for i := range p.Streams {
+		longest = max(longest, p.Streams[i].DurationSeconds())
+	}
+	return longest
+}
+
+// BitRate is the bitrate of the whole file in bits/s, 0 if unknown
+func (p *Probe) BitRate() int64 {
+	n, _ := strconv.ParseInt(p.Format.BitRate, 10, 64)
+	return n
+}
+
+// Video is the first video stream that isn't a cover art, nil if there's none
+func (p *Probe) Video() *ProbeStream {
+	for i := range p.Streams {
+		if s := &p.Streams[i]; s.CodecType == "video" && !s.IsCover() {
+			return s
+		}
+	}
+	return nil
+}
+
+// Cover is the picture attached to the file (cover art), nil if there's none
+func (p *Probe) Cover() *ProbeStream {
+	for i := range p.Streams {
+		if s := &p.Streams[i]; s.CodecType == "video" && s.IsCover() {
+			return s
+		}
+	}
+	return nil
+}
+
+// Audio is the first audio stream, nil if the file has no sound
+func (p *Probe) Audio() *ProbeStream {
+	if tracks := p.AudioTracks(); len(tracks) > 0 {
+		return tracks[0]
+	}
+	return nil
+}
+
+// AudioTracks lists the audio streams. Ex: the dubbed and the original audio of an MKV
+func (p *Probe) AudioTracks() []*ProbeStream {
+	var tracks []*ProbeStream
+	for i := range p.Streams {
+		if p.Streams[i].CodecType == "audio" {
+			tracks = append(tracks, &p.Streams[i])
+		}
+	}
+	return tracks
+}
+
+// Tag is the first of the keys found in the tags of the container
+func (p *Probe) Tag(keys ...string) string {
+	return tag(p.Format.Tags, keys...)
+}
+
+// IsCover reports if the stream is a picture attached to the file, not a video
+func (s *ProbeStream) IsCover() bool {
+	return s.Disposition["attached_pic"] == 1
+}
+
+// DurationSeconds is the duration of the stream. The MKVs only have it in the
+// DURATION tag. Ex: "00:10:05.123000000"
+func (s *ProbeStream) DurationSeconds() float64 {
+	if d := parseSeconds(s.Duration); d > 0 {
+		return d
+	}
+	return parseClock(tag(s.Tags, "duration"))
+}
+
+// BitRateValue is the bitrate of the stream in bits/s, 0 if unknown (MKV)
+func (s *ProbeStream) BitRateValue() int64 {
+	n, _ := strconv.ParseInt(s.BitRate, 10, 64)
+	return n
+}
+
+// Language of the stream (ISO 639-2, ex: "por"), empty if not tagged
+func (s *ProbeStream) Language() string {
+	return tag(s.Tags, "language")
+}
+
+// Rotation is how many degrees (0, 90, 180 or 270) the player turns the frames.
+// Phones record in landscape and mark the rotation in the display matrix.
+func (s *ProbeStream) Rotation() int {
+	var degrees float64
+	found := false
+	for _, sd := range s.SideData {
+		if sd.Type == "Display Matrix" || sd.Rotation != 0 {
+			degrees, found = sd.Rotation, true
+			break
+		}
+	}
+	// Before ffmpeg 5 it was the "rotate" tag
+	if !found {
+		degrees, _ = strconv.ParseFloat(tag(s.Tags, "rotate"), 64)
+	}
+
+	r := int(math.Round(degrees/90)) * 90 % 360
+	if r < 0 {
+		r += 360
+	}
+	return r
+}
+
+// DisplaySize is the size of the video as it's shown: stretched by the sample
+// aspect ratio and turned by the rotation
+func (s *ProbeStream) DisplaySize() (int, int) {
+	width, height := s.Width, s.Height
+
+	if num, den, ok := parseRatio(s.SampleAspectRatio); ok && num != den {
+		width = int(math.Round(float64(width) * float64(num) / float64(den)))
+	}
+	if r := s.Rotation(); r == 90 || r == 270 {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// parseSeconds reads a duration of ffprobe in seconds. "N/A" and empty are 0
+func parseSeconds(val string) float64 {
+	d, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// parseClock reads a duration as HH:MM:SS.fraction, 0 if invalid
+func parseClock(val string) float64 {
+	parts := strings.Split(strings.TrimSpace(val), ":")
+	if len(parts) != 3 {
+		return 0
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	sec, err3 := strconv.ParseFloat(parts[2], 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0
+	}
+	return float64(h*3600+m*60) + sec
+}
+
+// parseRatio reads a ratio as "num:den". ok is false when unknown ("N/A", "0:1")
+func parseRatio(val string) (int, int, bool) {
+	a, b, found := strings.Cut(val, ":")
+	if !found {
+		return 0, 0, false
+	}
+	num, err1 := strconv.Atoi(a)
+	den, err2 := strconv.Atoi(b)
+	if err1 != nil || err2 != nil || num <= 0 || den <= 0 {
+		return 0, 0, false
+	}
+	return num, den, true
+}
@@ -0,0 +1,123 @@
+package processor
+
+import "testing"
+
+func TestProbeDuration(t *testing.T) {
+	cases := []struct {
+		name string
+		json string
+		want float64
+	}{
+		{"formato", `{"format": {"duration": "300.500000"}, "streams": [{"codec_type": "video", "duration": "N/A"}]}`, 300.5},
+		{"mkv sem duração no formato", `{"format": {"duration": "N/A"}, "streams": [
+			{"codec_type": "video", "tags": {"DURATION": "00:10:05.123000000"}},
+			{"codec_type": "audio", "tags": {"DURATION": "00:10:05.100000000"}}]}`, 605.123},
+		{"stream", `{"format": {}, "streams": [{"codec_type": "audio", "duration": "42.0"}]}`, 42},
+		{"desconhecida", `{"format": {"duration": "N/A"}, "streams": [{"codec_type": "video", "duration": "N/A"}]}`, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := parseProbe([]byte(tc.json))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.Duration(); got < tc.want-0.001 || got > tc.want+0.001 {
+				t.Errorf("Duration() = %v, esperado %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProbeDisplaySize(t *testing.T) {
+	cases := []struct {
+		name          string
+		json          string
+		width, height int
+		rotation      int
+	}{
+		{"paisagem", `{"width": 1920, "height": 1080, "sample_aspect_ratio": "1:1"}`, 1920, 1080, 0},
+		{"celular em pé", `{"width": 1920, "height": 1080, "side_data_list": [{"side_data_type": "Display Matrix", "rotation": -90}]}`, 1080, 1920, 270},
+		{"virado", `{"width": 1280, "height": 720, "side_data_list": [{"side_data_type": "Display Matrix", "rotation": 180}]}`, 1280, 720, 180},
+		{"tag rotate antiga", `{"width": 1280, "height": 720, "tags": {"rotate": "90"}}`, 720, 1280, 90},
+		{"pixels não quadrados", `{"width": 720, "height": 576, "sample_aspect_ratio": "64:45"}`, 1024, 576, 0},
+		{"sar desconhecido", `{"width": 640, "height": 480, "sample_aspect_ratio": "0:1"}`, 640, 480, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := parseProbe([]byte(`{"streams": [` + tc.json + `]}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := &p.Streams[0]
+			if w, h := s.DisplaySize(); w != tc.width || h != tc.height {
+				t.Errorf("DisplaySize() = %dx%d, esperado %dx%d", w, h, tc.width, tc.height)
+			}
+			if r := s.Rotation(); r != tc.rotation {
+				t.Errorf("Rotation() = %d, esperado %d", r, tc.rotation)
+			}
+		})
+	}
+}
+
+func TestProbeStreams(t *testing.T) {
+	p, err := parseProbe([]byte(`{
+		"format": {"format_name": "matroska,webm", "bit_rate": "2500000", "tags": {"title": "Aula 1"}},
+		"streams": [
+			{"index": 0, "codec_type": "video", "codec_name": "mjpeg", "disposition": {"attached_pic": 1}},
+			{"index": 1, "codec_type": "video", "codec_name": "hevc", "width": 1920, "height": 1080},
+			{"index": 2, "codec_type": "audio", "codec_name": "opus", "channels": 2, "tags": {"language": "por"}},
+			{"index": 3, "codec_type": "audio", "codec_name": "aac", "channels": 6, "tags": {"language": "eng"}},
+			{"index": 4, "codec_type": "subtitle", "codec_name": "subrip"}
+		],
+		"chapters": [{"start_time": "0.000000", "end_time": "60.000000", "tags": {"title": "Introdução"}}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := p.Video(); v == nil || v.Index != 1 {
+		t.Errorf("Video() = %+v, esperado a faixa 1 (a 0 é a capa)", v)
+	}
+	if c := p.Cover(); c == nil || c.Index != 0 {
+		t.Errorf("Cover() = %+v, esperado a faixa 0", c)
+	}
+	tracks := p.AudioTracks()
+	if len(tracks) != 2 || tracks[0].Language() != "por" || tracks[1].Channels != 6 {
+		t.Errorf("AudioTracks() = %+v", tracks)
+	}
+	if p.BitRate() != 2500000 || p.Tag("title") != "Aula 1" || len(p.Chapters) != 1 {
+		t.Errorf("formato lido errado: %+v", p)
+	}
+
+	info := p.Container()
+	if info.VideoCodec != "hevc" || info.Codecs() != "hevc/opus+aac" || info.CanRemux() {
+		t.Errorf("Container() = %+v", info)
+	}
+}
+
+func TestContainerCanRemux(t *testing.T) {
+	cases := []struct {
+		name  string
+		video string
+		audio []string
+		want  bool
+	}{
+		{"h264 com aac", "h264", []string{"aac"}, true},
+		{"sem áudio", "h264", nil, true},
+		{"duas faixas compatíveis", "h264", []string{"aac", "mp3"}, true},
+		{"faixa ac3 extra", "h264", []string{"aac", "ac3"}, false},
+		{"hevc", "hevc", []string{"aac"}, false},
+		{"vp9 com opus", "vp9", []string{"opus"}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			info := &ContainerInfo{Format: "matroska,webm", VideoCodec: tc.video, AudioCodecs: tc.audio}
+			if got := info.CanRemux(); got != tc.want {
+				t.Errorf("CanRemux() = %v, esperado %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package processor
 
 import (
 	"fmt"
+	"html"
 	"os"
 
 	"github.com/FolcloreX/GopherGram/internal/domain"
@@ -17,6 +18,7 @@ func CalculateAssetsSize(assets []string) int64 {
 	return total
 }
 
+// CalculateVideosSize sums the videos of the modules, without their children (use Course.AllModules)
 func CalculateVideosSize(modules []*domain.Module) int64 {
 	var total int64
 	for _, mod := range modules {
@@ -27,11 +29,32 @@ func CalculateVideosSize(modules []*domain.Module) int64 {
 	return total
 }
 
-func FormatCourseCard(courseName string, totalBytes int64, totalSeconds int, logo string, inviteLink string) string {
+// CourseInfo is what the course card shows besides the totals
+type CourseInfo struct {
+	Title       string
+	Description string
+	Author      string
+	Year        int // 0 hides the release line
+}
+
+func FormatCourseCard(info CourseInfo, totalBytes int64, totalSeconds int, logo string, inviteLink string) string {
 	gb := float64(totalBytes) / (1024 * 1024 * 1024)
 	hours := totalSeconds / 3600
 	minutes := (totalSeconds % 3600) / 60
 
+	description := ""
+	if info.Description != "" {
+		description = fmt.Sprintf("<i>%s</i>\n\n", html.EscapeString(info.Description))
+	}
+
+	details := ""
+	if info.Author != "" {
+		details += fmt.Sprintf("👤 | Autor: %s\n", html.EscapeString(info.Author))
+	}
+	if info.Year > 0 {
+		details += fmt.Sprintf("🚀 | Lançamento: %d\n", info.Year)
+	}
+
 	buttonHTML := ""
 	if inviteLink != "" {
 		buttonHTML = fmt.Sprintf("\n\n👉 <a href=\"%s\"><b>CLIQUE PARA ACESSAR</b></a> 👈", inviteLink)
@@ -39,15 +62,18 @@ func FormatCourseCard(courseName string, totalBytes int64, totalSeconds int, log
 
 	return fmt.Sprintf(
 		"🎓 <b>%s</b>\n\n"+
+			"%s"+
 			"💾 | Tamanho Total: %.2f GB\n"+
 			"⏳ | Duração Total: %dh %02dm\n"+
-			"🚀 | Lançamento: 2024\n\n"+
+			"%s\n"+
 			"%s\n\n"+
 			"%s",
-		courseName,
+		html.EscapeString(info.Title),
+		description,
 		gb,
 		hours,
 		minutes,
+		details,
 		logo,
 		buttonHTML,
 	)
@@ -66,3 +92,17 @@ func FormatChannelBio(totalBytes int64, totalSeconds int, inviteLink, logo strin
 		gb, hours, minutes, inviteLink, logo,
 	)
 }
+
+// FormatSize shows the bytes in the biggest unit that fits. Ex: 1.50 GB
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit && exp < 3; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %cB", float64(bytes)/float64(div), "KMGT"[exp])
+}
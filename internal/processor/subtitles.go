@@ -0,0 +1,110 @@
+package processor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/FolcloreX/GopherGram/internal/domain"
+)
+
+// ErrNoSoftSubtitles is returned by MuxSubtitles for containers that can't hold subtitle tracks (.avi)
+var ErrNoSoftSubtitles = errors.New("o formato não suporta legendas embutidas")
+
+// MuxSubtitles copies the video adding the subtitles as tracks that can be turned
+// on and off in the player. Nothing is re-encoded. outPath must have the same
+// extension of the video.
+func MuxSubtitles(videoPath string, subs []domain.Subtitle, outPath string) error {
+	// Each container has its own subtitle codec
+	var codec string
+	switch strings.ToLower(filepath.Ext(videoPath)) {
+	case ".mp4", ".mov", ".m4v":
+		codec = "mov_text"
+	case ".mkv":
+		codec = "srt"
+	case ".webm":
+		codec = "webvtt"
+	default:
+		return ErrNoSoftSubtitles
+	}
+
+	args := []string{"-y", "-i", videoPath}
+	for _, sub := range subs {
+		args = append(args, "-i", sub.Path)
+	}
+	args = append(args, "-map", "0")
+	for i := range subs {
+		args = append(args, "-map", fmt.Sprintf("%d:s", i+1))
+	}
+	args = append(args, "-c", "copy", "-c:s", codec)
+	if codec == "mov_text" {
+		args = append(args, "-movflags", "+faststart")
+	}
+	for i, sub := range subs {
+		if sub.Lang != "" {
+			args = append(args, fmt.Sprintf("-metadata:s:s:%d", i), "language="+sub.Lang)
+		}
+	}
+	args = append(args, outPath)
+
+	return runFFmpeg(args...)
+}
+
+// BurnSubtitles re-encodes the video drawing the subtitle in the frames.
+// It's slow, but the subtitle shows in every player. outPath is always a .mp4.
+func BurnSubtitles(videoPath string, sub domain.Subtitle, outPath string) error {
+	return runFFmpeg(
+		"-y",
+		"-i", videoPath,
+		"-vf", "subtitles="+escapeFilterPath(sub.Path),
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-crf", "20",
+		"-c:a", "aac",
+		"-b:a", "192k",
+		"-movflags", "+faststart",
+		outPath,
+	)
+}
+
+// PickSubtitle is the subtitle of the language (pt matches pt-BR), or the first one
+func PickSubtitle(subs []domain.Subtitle, lang string) domain.Subtitle {
+	if lang != "" {
+		for _, sub := range subs {
+			if strings.EqualFold(sub.Lang, lang) {
+				return sub
+			}
+		}
+		for _, sub := range subs {
+			if prefix, _, _ := strings.Cut(sub.Lang, "-"); strings.EqualFold(prefix, lang) {
+				return sub
+			}
+		}
+	}
+	return subs[0]
+}
+
+// escapeFilterPath quotes the path to be used inside a filter of -vf, where
+// ":" and "'" have a meaning (Windows paths have ":"). The "'" goes through
+// the two levels of escaping of the filtergraph.
+func escapeFilterPath(path string) string {
+	path = filepath.ToSlash(path)
+	path = strings.ReplaceAll(path, ":", `\:`)
+	path = strings.ReplaceAll(path, "'", `'\\\''`)
+	return "'" + path + "'"
+}
+
+func runFFmpeg(args ...string) error {
+	cmd := exec.Command("ffmpeg", args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg falhou: %s | Log: %s", err, stderr.String())
+	}
+	return nil
+}
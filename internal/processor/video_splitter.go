@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"os"
@@ -10,51 +11,83 @@ import (
 	"strings"
 )
 
-type FFmpegSplitter struct{}
+type FFmpegSplitter struct {
+	OutDir string // Where the parts are written, next to the video if empty
+}
+
+// How many times a part still over the limit is splitted again
+const maxResplits = 2
 
-// SplitVideo divide the files if it's exceed the limit 2GB.
+// SplitVideo divides the file if it exceeds the limit (domain.MaxFileSize, a bit less than 2GiB).
+// The cuts are made on keyframes, chosen by the size of the packets between them,
+// so every part fits in the limit even with variable bitrate.
 // Return the list of generated files or original if not splitted.
 func (fs *FFmpegSplitter) SplitVideo(inputFile string, limitBytes int64) ([]string, error) {
+	return fs.split(inputFile, limitBytes, 0)
+}
+
+func (fs *FFmpegSplitter) split(inputFile string, limitBytes int64, resplits int) ([]string, error) {
 	info, err := os.Stat(inputFile)
 	if err != nil {
 		return nil, err
 	}
 
-	safeLimit := int64(float64(limitBytes) * 0.95)
-
-	if info.Size() <= safeLimit {
+	if info.Size() <= limitBytes {
 		return []string{inputFile}, nil
 	}
 
 	fmt.Printf("🔄 Dividindo vídeo grande: %s (%d bytes)\n", filepath.Base(inputFile), info.Size())
 
-	durationSec, err := getVideoDuration(inputFile)
+	packets, err := probePackets(inputFile)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao obter duração: %w", err)
+		return nil, fmt.Errorf("erro ao ler os pacotes: %w", err)
+	}
+
+	cuts := planCuts(packets, partTarget(limitBytes))
+	if len(cuts) == 0 {
+		return nil, fmt.Errorf("nenhum keyframe para dividir %s", filepath.Base(inputFile))
 	}
 
-	// Ex: 5GB file, 2GB limit -> 3 parts
-	numParts := int(info.Size()/safeLimit) + 1
-	segmentTime := durationSec / float64(numParts)
+	times := make([]string, len(cuts))
+	for i, t := range cuts {
+		times[i] = strconv.FormatFloat(t, 'f', 3, 64)
+	}
 
-	// output_%03d.mp4 generates output_000.mp4, output_001.mp4...
+	outDir := fs.OutDir
+	if outDir == "" {
+		outDir = filepath.Dir(inputFile)
+	}
+
+	// aula-part-%03d.mp4 generates aula-part-001.mp4, aula-part-002.mp4...
 	ext := filepath.Ext(inputFile)
 	baseName := strings.TrimSuffix(filepath.Base(inputFile), ext)
-	outputPattern := filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-part-%%1d%s", baseName, ext))
+	outputPattern := filepath.Join(outDir, fmt.Sprintf("%s-part-%%03d%s", baseName, ext))
+	listPath := filepath.Join(outDir, baseName+"-parts.txt")
+	defer os.Remove(listPath)
 
 	// Command FFmpeg:
 	// -c copy: Copy streams (fast, no re-encode)
 	// -f segment: Use segments muxer
+	// -segment_times: Cut on the first keyframe after each time
+	// -segment_list: Writes the name of each part created, in order
 	// -reset_timestamps 1: Each part will be "touchable" individually
-	cmd := exec.Command("ffmpeg",
+	args := []string{
+		"-y",
 		"-i", inputFile,
 		"-c", "copy",
 		"-map", "0",
 		"-f", "segment",
-		"-segment_time", fmt.Sprintf("%.2f", segmentTime),
+		"-segment_times", strings.Join(times, ","),
+		"-segment_start_number", "1",
+		"-segment_list", listPath,
+		"-segment_list_type", "flat",
 		"-reset_timestamps", "1",
-		outputPattern,
-	)
+	}
+	// The parts must stream too, with the index at the start
+	if VideoMimeType(inputFile) == "video/mp4" || VideoMimeType(inputFile) == "video/quicktime" {
+		args = append(args, "-segment_format_options", "movflags=+faststart")
+	}
+	cmd := exec.Command("ffmpeg", append(args, outputPattern)...)
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
@@ -63,28 +96,206 @@ func (fs *FFmpegSplitter) SplitVideo(inputFile string, limitBytes int64) ([]stri
 		return nil, fmt.Errorf("ffmpeg falhou: %s | Log: %s", err, stderr.String())
 	}
 
-	// TODO, improve this mess
-	matches, err := filepath.Glob(filepath.Join(filepath.Dir(inputFile), fmt.Sprintf("%s-part-*%s", baseName, ext)))
+	matches, err := readSegmentList(listPath, outDir)
 	if err != nil {
 		return nil, err
 	}
 
-	return matches, nil
+	// The muxer overhead can still push a part over the limit, split it again
+	var parts []string
+	for _, part := range matches {
+		info, err := os.Stat(part)
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() <= limitBytes {
+			parts = append(parts, part)
+			continue
+		}
+		if resplits >= maxResplits {
+			return nil, fmt.Errorf("parte %s continua maior que o limite (%d bytes)", filepath.Base(part), info.Size())
+		}
+
+		fmt.Printf("⚠️ Parte %s passou do limite, dividindo de novo\n", filepath.Base(part))
+		sub, err := fs.split(part, limitBytes, resplits+1)
+		if err != nil {
+			return nil, err
+		}
+		os.Remove(part)
+		parts = append(parts, sub...)
+	}
+
+	return parts, nil
+}
+
+// readSegmentList reads the parts written by the segment muxer, one name per line
+func readSegmentList(listPath, outDir string) ([]string, error) {
+	content, err := os.ReadFile(listPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler a lista de partes: %w", err)
+	}
+
+	var parts []string
+	for _, line := range strings.Split(string(content), "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" {
+			continue
+		}
+		parts = append(parts, filepath.Join(outDir, filepath.Base(name)))
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("o ffmpeg não gerou nenhuma parte")
+	}
+	return parts, nil
+}
+
+// partTarget is the size aimed for each part, the margin is for the headers
+// and the index (moov) the muxer writes in every part
+func partTarget(limitBytes int64) int64 {
+	return limitBytes - limitBytes/100
+}
+
+// EstimateVideoParts is the number of parts SplitVideo splits a video of the size in.
+// Ex: 5GB file, 2GB limit -> 3 parts
+func EstimateVideoParts(size, limitBytes int64) int {
+	if size <= limitBytes {
+		return 1
+	}
+	target := partTarget(limitBytes)
+	return int((size + target - 1) / target)
+}
+
+// packet is a packet of the video, in the order they are in the file
+type packet struct {
+	time float64 // Seconds
+	size int64
+	key  bool // Keyframe of the video stream, where a part can start
 }
 
-// getVideoDuration uses ffprobe to get the exact duration
-func getVideoDuration(path string) (float64, error) {
+// probePackets lists the packets of every stream through ffprobe. It reads
+// the whole file, but only the headers of the packets.
+func probePackets(path string) ([]packet, error) {
+	// Command: ffprobe -v error -show_entries packet=codec_type,pts_time,dts_time,size,flags -of csv=p=0 input.mp4
+	// Output example: "video,12.345000,12.300000,45678,K__"
 	cmd := exec.Command("ffprobe",
 		"-v", "error",
-		"-show_entries", "format=duration",
-		"-of", "default=noprint_wrappers=1:nokey=1",
+		"-show_entries", "packet=codec_type,pts_time,dts_time,size,flags",
+		"-of", "csv=p=0",
 		path,
 	)
-	out, err := cmd.Output()
+	out, err := cmd.StdoutPipe()
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var packets []packet
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ",")
+		if len(fields) < 5 {
+			continue
+		}
+
+		// The pts is N/A in some packets, the dts is close enough
+		t, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			if t, err = strconv.ParseFloat(fields[2], 64); err != nil {
+				continue
+			}
+		}
+		size, _ := strconv.ParseInt(fields[3], 10, 64)
+
+		packets = append(packets, packet{
+			time: t,
+			size: size,
+			key:  fields[0] == "video" && strings.HasPrefix(fields[4], "K"),
+		})
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("erro no ffprobe: %w", err)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return packets, nil
+}
+
+// planCuts chooses the keyframes where the video is cut, in seconds from the
+// start of the output. Every part stays under target bytes (unless a single
+// GOP is bigger) and the parts have about the same size.
+func planCuts(packets []packet, target int64) []float64 {
+	type keyframe struct {
+		time   float64
+		offset int64 // Bytes before the keyframe
 	}
 
-	val := strings.TrimSpace(string(out))
-	return strconv.ParseFloat(val, 64)
+	var keys []keyframe
+	var total int64
+	start := -1.0
+	for _, p := range packets {
+		if start < 0 || p.time < start {
+			start = p.time
+		}
+		if p.key && total > 0 {
+			keys = append(keys, keyframe{time: p.time, offset: total})
+		}
+		total += p.size
+	}
+
+	var cuts []float64
+	var partStart int64
+	i := 0
+	for total-partStart > target {
+		remaining := total - partStart
+		parts := (remaining + target - 1) / target
+		ideal := partStart + remaining/parts
+
+		// The keyframe closest to the ideal size that doesn't pass the target
+		for i < len(keys) && (keys[i].offset <= partStart || (len(cuts) > 0 && keys[i].time-start <= cuts[len(cuts)-1])) {
+			i++
+		}
+		best := -1
+		for ; i < len(keys) && keys[i].offset-partStart <= target; i++ {
+			if best < 0 || abs(keys[i].offset-ideal) < abs(keys[best].offset-ideal) {
+				best = i
+			}
+		}
+		if best < 0 {
+			// A GOP bigger than the target, the part can't be smaller
+			if i >= len(keys) {
+				break
+			}
+			best = i
+		}
+
+		// The muxer cuts on the first keyframe at or after the time, with the timestamps starting at 0
+		cuts = append(cuts, max(keys[best].time-start-0.001, 0))
+		partStart = keys[best].offset
+		i = best + 1
+	}
+	return cuts
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// VideoDuration returns the duration in seconds of the video, without generating any file
+func VideoDuration(path string) (int, error) {
+	probe, err := ProbeFile(path)
+	if err != nil {
+		return 0, err
+	}
+	duration := probe.Duration()
+	if duration == 0 {
+		return 0, fmt.Errorf("duração desconhecida: %s", filepath.Base(path))
+	}
+	return int(duration), nil
 }
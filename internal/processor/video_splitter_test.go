@@ -0,0 +1,94 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/FolcloreX/GopherGram/internal/domain"
+)
+
+// gops builds the packets of a video with a keyframe every second, each GOP with the size given
+func gops(sizes ...int64) []packet {
+	var packets []packet
+	for i, size := range sizes {
+		t := float64(i) + 10 // The timestamps don't start at 0
+		packets = append(packets,
+			packet{time: t, size: size / 2, key: true},
+			packet{time: t, size: size / 4},                     // Audio
+			packet{time: t + 0.5, size: size - size/2 - size/4}, // Rest of the GOP
+		)
+	}
+	return packets
+}
+
+// partSizes is the size of each part cut by planCuts
+func partSizes(sizes []int64, cuts []float64) []int64 {
+	var parts []int64
+	var current int64
+	next := 0
+	for i, size := range sizes {
+		if next < len(cuts) && float64(i) > cuts[next] {
+			parts = append(parts, current)
+			current = 0
+			next++
+		}
+		current += size
+	}
+	return append(parts, current)
+}
+
+func TestPlanCuts(t *testing.T) {
+	cases := []struct {
+		name   string
+		sizes  []int64
+		target int64
+		want   []int64
+	}{
+		{"cabe inteiro", []int64{10, 10, 10}, 30, []int64{30}},
+		{"partes iguais", []int64{10, 10, 10, 10, 10, 10}, 40, []int64{30, 30}},
+		{"bitrate variável", []int64{5, 5, 5, 30, 30, 5, 5}, 50, []int64{45, 40}},
+		{"três partes", []int64{10, 10, 10, 10, 10, 10, 10, 10, 10}, 35, []int64{30, 30, 30}},
+		{"GOP maior que o limite", []int64{10, 60, 10}, 40, []int64{10, 60, 10}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cuts := planCuts(gops(tc.sizes...), tc.target)
+			got := partSizes(tc.sizes, cuts)
+			if len(got) != len(tc.want) {
+				t.Fatalf("partes = %v (cortes %v), esperado %v", got, cuts, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("partes = %v (cortes %v), esperado %v", got, cuts, tc.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestEstimateVideoParts(t *testing.T) {
+	const limit = 2000
+	for size, want := range map[int64]int{1000: 1, 2000: 1, 2001: 2, 3960: 2, 3961: 3} {
+		if got := EstimateVideoParts(size, limit); got != want {
+			t.Errorf("EstimateVideoParts(%d) = %d, esperado %d", size, got, want)
+		}
+	}
+}
+
+func TestEstimateVideoPartsTelegramLimit(t *testing.T) {
+	// Telegram rejects files over 4000 chunks of 512KB, less than 2GiB
+	cases := map[int64]int{
+		domain.MaxFileSize:     1,
+		2_097_152_001:          2,
+		2 * 1024 * 1024 * 1024: 2,
+	}
+	for size, want := range cases {
+		if got := EstimateVideoParts(size, domain.MaxFileSize); got != want {
+			t.Errorf("EstimateVideoParts(%d) = %d, esperado %d", size, got, want)
+		}
+	}
+	if target := partTarget(domain.MaxFileSize); target >= 2_097_152_000 {
+		t.Errorf("partes de %d bytes passam do limite do Telegram", target)
+	}
+}
@@ -0,0 +1,211 @@
+package scanner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFile is the name of the files with ignore rules, like the .gitignore.
+// It can be in any folder of the course, its rules apply to that folder.
+const IgnoreFile = ".gopherignore"
+
+// ErrInvalidIgnore is returned for a pattern that can't be parsed
+var ErrInvalidIgnore = errors.New("regra de ignorar inválida")
+
+// DefaultIgnores are the junk files that are never part of a course: files of
+// the OS and partial downloads. A .gopherignore can bring them back with "!".
+var DefaultIgnores = []string{
+	".DS_Store",
+	"._*",
+	"Thumbs.db",
+	"desktop.ini",
+	"*.part",
+	"*.crdownload",
+	"*.download",
+	"*.!qB",
+	"*.tmp",
+}
+
+// rule is one line of a .gopherignore (or a pattern of the manifest, the
+// defaults or the CLI). The last rule that matches wins, as in the .gitignore.
+type rule struct {
+	pattern  string
+	negate   bool   // "!" brings back a file ignored by a previous rule
+	dirOnly  bool   // Ends with "/"
+	anchored bool   // Has a "/" in the middle, matches the path instead of the name
+	fold     bool   // Ignore case, for the defaults (thumbs.db on Windows)
+	base     string // Folder of the .gopherignore, relative to the root
+	source   string // Where the rule came from, shown in the plan
+}
+
+func newRule(line, base, source string) (rule, error) {
+	r := rule{base: base, source: source}
+
+	line = filepath.ToSlash(line)
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	line = strings.TrimPrefix(line, "./")
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return r, fmt.Errorf("padrão vazio")
+	}
+
+	for _, segment := range strings.Split(line, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return r, fmt.Errorf("padrão inválido: %q", line)
+		}
+	}
+	r.pattern = line
+	return r, nil
+}
+
+func (r rule) match(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	// The rules of a .gopherignore only see the files below its folder
+	if r.base != "" {
+		rest, ok := strings.CutPrefix(rel, r.base+"/")
+		if !ok {
+			return false
+		}
+		rel = rest
+	}
+
+	pattern := r.pattern
+	if r.fold {
+		pattern, rel = strings.ToLower(pattern), strings.ToLower(rel)
+	}
+	if !r.anchored {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments matches the path folder by folder, "**" matches any number of folders
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// ignoreRules is every rule of the scan, in the order they are applied
+type ignoreRules struct {
+	rules   []rule
+	include []rule // If any, only the files that match one are kept
+	cli     []rule // --exclude, applied after everything else
+}
+
+// parseRules creates the rules of patterns relative to the root
+func parseRules(patterns []string, source string) ([]rule, error) {
+	var rules []rule
+	for _, pattern := range patterns {
+		r, err := newRule(pattern, "", source)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidIgnore, source, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// loadDir reads the .gopherignore of the folder (relative to the root), if any
+func (ig *ignoreRules) loadDir(root, rel string) error {
+	file := filepath.Join(root, rel, IgnoreFile)
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao ler %s: %w", file, err)
+	}
+	defer f.Close()
+
+	base := filepath.ToSlash(rel)
+	if base == "." {
+		base = ""
+	}
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		source := fmt.Sprintf("%s:%d", path.Join(base, IgnoreFile), n)
+		r, err := newRule(line, base, source)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidIgnore, source, err)
+		}
+		ig.rules = append(ig.rules, r)
+	}
+	return scanner.Err()
+}
+
+// reason tells why the file or folder (relative to the root) is ignored, empty if it's not
+func (ig *ignoreRules) reason(rel string, isDir bool) string {
+	rel = filepath.ToSlash(rel)
+
+	reason := ""
+	for _, r := range ig.rules {
+		if r.match(rel, isDir) {
+			reason = ""
+			if !r.negate {
+				reason = r.source + " (" + r.pattern + ")"
+			}
+		}
+	}
+
+	// The command line has the last word
+	for _, r := range ig.cli {
+		if !r.negate && r.match(rel, isDir) {
+			return r.source + " (" + r.pattern + ")"
+		}
+	}
+	if reason != "" {
+		return reason
+	}
+
+	// The folders are always walked, the include only filters the files
+	if len(ig.include) > 0 && !isDir {
+		for _, r := range ig.include {
+			if r.match(rel, isDir) {
+				return ""
+			}
+		}
+		return "fora do --include"
+	}
+	return ""
+}
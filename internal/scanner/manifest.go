@@ -0,0 +1,203 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/FolcloreX/GopherGram/internal/domain"
+)
+
+// ManifestFiles are the names of the manifest in the root of the course, the first found is used
+var ManifestFiles = []string{"course.yaml", "course.yml", "course.json"}
+
+// ErrInvalidManifest is returned when the manifest can't be read or references
+// modules, videos or files that don't exist
+var ErrInvalidManifest = errors.New("manifesto inválido")
+
+// Manifest is the optional course.yaml (or course.json) in the root of the
+// course. What it sets overrides what the scanner infers from the folders.
+type Manifest struct {
+	Title       string `yaml:"title" json:"title"`
+	Description string `yaml:"description" json:"description"`
+	Author      string `yaml:"author" json:"author"`
+	Year        int    `yaml:"year" json:"year"`
+	Cover       string `yaml:"cover" json:"cover"` // Relative to the root of the course
+
+	// What to do with the videos Telegram can't stream: none, remux or transcode (overrides VIDEO_CONVERT)
+	Convert string `yaml:"convert" json:"convert"`
+
+	// Order of the modules among their siblings, the ones not listed come after in the natural order
+	Modules []ModuleManifest `yaml:"modules" json:"modules"`
+
+	// Title of the videos, by the path relative to the root. Ex: "01 Intro/01 aula.mp4": "Instalação"
+	Videos map[string]string `yaml:"videos" json:"videos"`
+
+	// Files and folders left out of the upload, same syntax of the .gopherignore.
+	// Patterns without "/" match the name in any folder, the others the path relative to the root.
+	Exclude []string `yaml:"exclude" json:"exclude"`
+
+	path string // File the manifest was read from
+}
+
+type ModuleManifest struct {
+	Folder string `yaml:"folder" json:"folder"` // Relative to the root. Ex: "01 Intro" or "01 Intro/1. Boas-vindas"
+	Name   string `yaml:"name" json:"name"`     // Name shown in the channel, defaults to the folder
+}
+
+// LoadManifest reads the manifest of the course. Returns nil if there's none.
+func LoadManifest(root string) (*Manifest, error) {
+	for _, name := range ManifestFiles {
+		file := filepath.Join(root, name)
+		data, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler %s: %w", name, err)
+		}
+
+		m := &Manifest{path: file}
+		if filepath.Ext(name) == ".json" {
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.DisallowUnknownFields()
+			err = dec.Decode(m)
+		} else {
+			dec := yaml.NewDecoder(bytes.NewReader(data))
+			dec.KnownFields(true)
+			err = dec.Decode(m)
+		}
+		// An empty file is an empty manifest
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidManifest, name, err)
+		}
+
+		if err := m.validate(); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidManifest, name, err)
+		}
+		return m, nil
+	}
+	return nil, nil
+}
+
+// validate checks what can be checked without the scanned course
+func (m *Manifest) validate() error {
+	if m.Year < 0 {
+		return fmt.Errorf("year inválido: %d", m.Year)
+	}
+	if m.Convert != "" && !domain.IsConvertPolicy(m.Convert) {
+		return fmt.Errorf("convert inválido: %q (use none, remux ou transcode)", m.Convert)
+	}
+
+	seen := map[string]bool{}
+	for _, mod := range m.Modules {
+		if mod.Folder == "" {
+			return errors.New("módulo sem folder")
+		}
+		folder := cleanPattern(mod.Folder)
+		if seen[folder] {
+			return fmt.Errorf("módulo repetido: %s", mod.Folder)
+		}
+		seen[folder] = true
+	}
+
+	for _, pattern := range m.Exclude {
+		if _, err := newRule(pattern, "", "exclude"); err != nil {
+			return fmt.Errorf("exclude: %v", err)
+		}
+	}
+	return nil
+}
+
+// cleanPattern accepts "pasta/" and "./arquivo" as in the .gitignore
+func cleanPattern(pattern string) string {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	return strings.TrimSuffix(pattern, "/")
+}
+
+// apply merges the manifest over the scanned course. Referencing a module or
+// video that was not found is an error, probably a typo or a renamed file.
+func (m *Manifest) apply(course *domain.Course) error {
+	if m.Title != "" {
+		course.Title = m.Title
+	}
+	course.Description = m.Description
+	course.Author = m.Author
+	course.Year = m.Year
+	course.Convert = m.Convert
+
+	if m.Cover != "" {
+		cover := filepath.FromSlash(m.Cover)
+		if !filepath.IsAbs(cover) {
+			cover = filepath.Join(course.RootPath, cover)
+		}
+		if _, err := os.Stat(cover); err != nil {
+			return fmt.Errorf("capa não encontrada: %s", m.Cover)
+		}
+		course.CoverPath = cover
+	}
+
+	// Modules are found by their folder, the listed ones go first among their siblings
+	byFolder := map[string]*domain.Module{}
+	for _, mod := range course.AllModules() {
+		byFolder[mod.Folder] = mod
+	}
+
+	rank := map[*domain.Module]int{}
+	for i, entry := range m.Modules {
+		mod, ok := byFolder[cleanPattern(entry.Folder)]
+		if !ok {
+			return fmt.Errorf("módulo não encontrado: %s", entry.Folder)
+		}
+		rank[mod] = i
+		if entry.Name != "" {
+			mod.Name = entry.Name
+		}
+	}
+
+	course.Modules = reorder(course.Modules, rank)
+	for _, mod := range course.AllModules() {
+		mod.Children = reorder(mod.Children, rank)
+	}
+
+	byPath := map[string]*domain.Video{}
+	for _, vid := range course.Videos() {
+		rel, _ := filepath.Rel(course.RootPath, vid.FilePath)
+		byPath[filepath.ToSlash(rel)] = vid
+	}
+	for rel, title := range m.Videos {
+		vid, ok := byPath[cleanPattern(rel)]
+		if !ok {
+			return fmt.Errorf("vídeo não encontrado: %s", rel)
+		}
+		vid.Title = title
+	}
+	return nil
+}
+
+// reorder puts the modules listed in the manifest first, in the manifest order
+func reorder(mods []*domain.Module, rank map[*domain.Module]int) []*domain.Module {
+	ordered := slices.Clone(mods)
+	slices.SortStableFunc(ordered, func(a, b *domain.Module) int {
+		ra, listedA := rank[a]
+		rb, listedB := rank[b]
+		switch {
+		case listedA && listedB:
+			return ra - rb
+		case listedA:
+			return -1
+		case listedB:
+			return 1
+		}
+		return 0
+	})
+	return ordered
+}
@@ -4,25 +4,54 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/FolcloreX/GopherGram/internal/domain"
+	"github.com/FolcloreX/GopherGram/internal/processor"
 )
 
 type Scanner struct {
 	RootPath string
+	Include  []string // Globs of the files to keep (--include), empty keeps everything
+	Exclude  []string // Globs of the files and folders to leave out (--exclude)
+	MaxDepth int      // Levels of subfolders that become modules, 0 is no limit
+
+	// Name of the module of the videos in the root, DefaultRootModule if empty
+	RootModule string
 }
 
+// DefaultRootModule is the module of the videos placed in the root of the course.
+// It comes before the folders, in the manifest it's the folder ".".
+const DefaultRootModule = "Introdução"
+
 func New(rootPath string) *Scanner {
 	return &Scanner{RootPath: rootPath}
 }
 
-// Scan go through the folder and construct the file tree
+// Scan go through the folder and construct the file tree.
+// The manifest (course.yaml), if any, is merged over what was found.
 func (s *Scanner) Scan() (*domain.Course, error) {
+	manifest, err := LoadManifest(s.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ig, err := s.ignoreRules(manifest)
+	if err != nil {
+		return nil, err
+	}
+
 	course := &domain.Course{
 		RootPath: s.RootPath,
 		Modules:  []*domain.Module{},
 		Assets:   []string{},
+		Title:    filepath.Base(s.RootPath),
+	}
+
+	if err := ig.loadDir(s.RootPath, "."); err != nil {
+		return nil, err
 	}
 
 	entries, err := os.ReadDir(s.RootPath)
@@ -34,14 +63,29 @@ func (s *Scanner) Scan() (*domain.Course, error) {
 		return naturalLess(entries[i].Name(), entries[j].Name())
 	})
 
-	globalVideoSequence := 1
+	rootName := s.RootModule
+	if rootName == "" {
+		rootName = DefaultRootModule
+	}
+	rootModule := &domain.Module{Name: rootName, Videos: []*domain.Video{}, Folder: "."}
+	var rootSubs []string
 
 	for _, entry := range entries {
 		fullPath := filepath.Join(s.RootPath, entry.Name())
+		if s.skip(course, ig, fullPath, entry.IsDir()) {
+			continue
+		}
 
-		// TODO for now, everything that's not a folder in the root will be considered an asset
+		// The videos in the root go to the leading module, the other files are assets
 		if !entry.IsDir() {
-			course.Assets = append(course.Assets, fullPath)
+			switch {
+			case domain.IsMedia(entry.Name()):
+				rootModule.Videos = append(rootModule.Videos, newVideo(fullPath, entry))
+			case domain.IsSubtitle(entry.Name()):
+				rootSubs = append(rootSubs, fullPath)
+			default:
+				course.Assets = append(course.Assets, fullPath)
+			}
 			continue
 		}
 
@@ -49,54 +93,217 @@ func (s *Scanner) Scan() (*domain.Course, error) {
 		module := &domain.Module{
 			Name:   entry.Name(),
 			Videos: []*domain.Video{},
+			Folder: entry.Name(),
 		}
 
-		err := filepath.WalkDir(fullPath, func(path string, d os.DirEntry, err error) error {
-			if err != nil {
-				return err
+		if err := s.scanModule(course, ig, module, fullPath, 1); err != nil {
+			return nil, fmt.Errorf("erro ao varrer módulo %s: %w", module.Name, err)
+		}
+
+		// Only add the module if there's content
+		if hasVideos(module) {
+			course.Modules = append(course.Modules, module)
+		}
+	}
+
+	course.Assets = append(course.Assets, pairSubtitles(rootModule.Videos, rootSubs)...)
+	if hasVideos(rootModule) {
+		course.Modules = append([]*domain.Module{rootModule}, course.Modules...)
+	}
+
+	if manifest != nil {
+		if err := manifest.apply(course); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidManifest, filepath.Base(manifest.path), err)
+		}
+	}
+
+	numberVideos(course)
+	return course, nil
+}
+
+// scanModule reads the folder of the module. The subfolders become child modules
+// until MaxDepth, the deeper ones have their videos added to the module.
+func (s *Scanner) scanModule(course *domain.Course, ig *ignoreRules, module *domain.Module, dir string, depth int) error {
+	rel, _ := filepath.Rel(s.RootPath, dir)
+	if err := ig.loadDir(s.RootPath, rel); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return naturalLess(entries[i].Name(), entries[j].Name())
+	})
+
+	// The subtitles are paired with the videos of the same folder
+	var videos []*domain.Video
+	var subs []string
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if s.skip(course, ig, path, entry.IsDir()) {
+			continue
+		}
+
+		if entry.IsDir() {
+			// Too deep, flattened into the module
+			if s.MaxDepth > 0 && depth >= s.MaxDepth {
+				if err := s.scanModule(course, ig, module, path, depth); err != nil {
+					return err
+				}
+				continue
 			}
 
-			if d.IsDir() {
-				return nil
+			child := &domain.Module{
+				Name:   entry.Name(),
+				Videos: []*domain.Video{},
+				Folder: module.Folder + "/" + entry.Name(),
+				Parent: module,
 			}
+			if err := s.scanModule(course, ig, child, path, depth+1); err != nil {
+				return err
+			}
+			if hasVideos(child) {
+				module.Children = append(module.Children, child)
+			}
+			continue
+		}
 
-			if domain.IsVideo(d.Name()) {
-				vid := &domain.Video{
-					FilePath: path,
-					FileName: d.Name(),
-					Title:    d.Name(), // Formated latter
-					Module:   module.Name,
-					ID:       fmt.Sprintf("%s%03d", domain.HashTagVideo, globalVideoSequence),
-					Sequence: globalVideoSequence,
-				}
+		switch {
+		case domain.IsMedia(entry.Name()):
+			vid := newVideo(path, entry)
+			videos = append(videos, vid)
+			module.Videos = append(module.Videos, vid)
+		case domain.IsSubtitle(entry.Name()):
+			subs = append(subs, path)
+		default:
+			course.Assets = append(course.Assets, path)
+		}
+	}
 
-				info, _ := d.Info()
-				if info != nil {
-					vid.Size = info.Size()
-				}
+	course.Assets = append(course.Assets, pairSubtitles(videos, subs)...)
+	return nil
+}
 
-				module.Videos = append(module.Videos, vid)
-				globalVideoSequence++
-			} else {
-				course.Assets = append(course.Assets, path)
+// pairSubtitles adds the subtitles to the videos with the same name, the language
+// can come between the name and the extension (aula.pt-BR.srt). Returns the
+// subtitles without a video, they stay as assets.
+func pairSubtitles(videos []*domain.Video, subs []string) (unpaired []string) {
+	for _, sub := range subs {
+		subName := strings.TrimSuffix(filepath.Base(sub), filepath.Ext(sub))
+
+		// The longest name wins: aula.parte2.srt is of aula.parte2.mp4, not of aula.mp4
+		var match *domain.Video
+		lang := ""
+		for _, vid := range videos {
+			base := strings.TrimSuffix(vid.FileName, filepath.Ext(vid.FileName))
+			if match != nil && len(base) <= len(strings.TrimSuffix(match.FileName, filepath.Ext(match.FileName))) {
+				continue
+			}
+			if subName == base {
+				match, lang = vid, ""
+			} else if rest, ok := strings.CutPrefix(subName, base+"."); ok && !strings.Contains(rest, ".") {
+				match, lang = vid, rest
 			}
-			return nil
-		})
+		}
+
+		if match == nil {
+			unpaired = append(unpaired, sub)
+			continue
+		}
+		match.Subtitles = append(match.Subtitles, domain.Subtitle{Path: sub, Lang: lang})
+	}
+	return unpaired
+}
+
+func newVideo(path string, entry os.DirEntry) *domain.Video {
+	vid := &domain.Video{
+		Kind:     domain.MediaKindOf(entry.Name()),
+		FilePath: path,
+		FileName: entry.Name(),
+		Title:    entry.Name(), // Formated latter
+	}
+	if info, _ := entry.Info(); info != nil {
+		vid.Size = info.Size()
+	}
+	return vid
+}
+
+func hasVideos(module *domain.Module) bool {
+	return len(module.Videos) > 0 || len(module.Children) > 0
+}
 
+// ignoreRules puts together the rules that don't depend on the folders:
+// the defaults, the exclude of the manifest and the CLI globs.
+func (s *Scanner) ignoreRules(manifest *Manifest) (*ignoreRules, error) {
+	ig := &ignoreRules{}
+
+	defaults, err := parseRules(DefaultIgnores, "padrão")
+	if err != nil {
+		return nil, err
+	}
+	for i := range defaults {
+		defaults[i].fold = true
+	}
+	ig.rules = defaults
+
+	if manifest != nil {
+		rules, err := parseRules(manifest.Exclude, filepath.Base(manifest.path)+" exclude")
 		if err != nil {
-			return nil, fmt.Errorf("erro ao varrer módulo %s: %w", module.Name, err)
+			return nil, err
 		}
+		ig.rules = append(ig.rules, rules...)
+	}
 
-		// Order the videos inside the module
-		sort.Slice(module.Videos, func(i, j int) bool {
-			return naturalLess(module.Videos[i].FileName, module.Videos[j].FileName)
-		})
+	if ig.cli, err = parseRules(s.Exclude, "--exclude"); err != nil {
+		return nil, err
+	}
+	if ig.include, err = parseRules(s.Include, "--include"); err != nil {
+		return nil, err
+	}
+	return ig, nil
+}
 
-		// Only add the module if there's content
-		if len(module.Videos) > 0 {
-			course.Modules = append(course.Modules, module)
+// ReasonLeftover is the reason of the split parts and thumbnails of older versions
+const ReasonLeftover = "sobra de um upload anterior (rode clean)"
+
+// skip reports if the file or folder is left out, saving the reason in the course.
+// The manifest and the .gopherignore files are left out silently.
+func (s *Scanner) skip(course *domain.Course, ig *ignoreRules, path string, isDir bool) bool {
+	rel, _ := filepath.Rel(s.RootPath, path)
+	name := filepath.Base(path)
+
+	if !isDir {
+		if name == IgnoreFile || (rel == name && slices.Contains(ManifestFiles, name)) {
+			return true
 		}
 	}
 
-	return course, nil
+	reason := ig.reason(rel, isDir)
+	if reason == "" && !isDir && processor.IsLeftover(path) {
+		reason = ReasonLeftover
+	}
+	if reason == "" {
+		return false
+	}
+
+	course.Ignored = append(course.Ignored, domain.IgnoredFile{Path: path, Reason: reason})
+	return true
+}
+
+// numberVideos gives the IDs (#F001) and sequences following the final order of
+// the course, and the module of the captions
+func numberVideos(course *domain.Course) {
+	sequence := 1
+	for i, mod := range course.AllModules() {
+		mod.Sequence = i + 1
+		for _, vid := range mod.Videos {
+			vid.Module = mod.FullName()
+			vid.ID = fmt.Sprintf("%s%03d", domain.HashTagVideo, sequence)
+			vid.Sequence = sequence
+			sequence++
+		}
+	}
 }
@@ -0,0 +1,417 @@
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/FolcloreX/GopherGram/internal/domain"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScanWithoutManifest(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "Curso de Go")
+	writeFiles(t, root, map[string]string{
+		"01 Intro/10 fim.mp4":   "v",
+		"01 Intro/2 inicio.mp4": "v",
+		"01 Intro/slides.pdf":   "a",
+		"leia.txt":              "a",
+	})
+
+	course, err := New(root).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if course.Title != "Curso de Go" {
+		t.Errorf("título = %q, esperado o nome da pasta", course.Title)
+	}
+	videos := course.Modules[0].Videos
+	if videos[0].FileName != "2 inicio.mp4" || videos[0].ID != "F001" || videos[1].ID != "F002" {
+		t.Errorf("ordem/IDs errados: %s=%s, %s=%s", videos[0].FileName, videos[0].ID, videos[1].FileName, videos[1].ID)
+	}
+	if len(course.Assets) != 2 {
+		t.Errorf("assets = %v", course.Assets)
+	}
+}
+
+func TestScanMergesManifest(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"01 Intro/01 a.mp4":     "v",
+		"01 Intro/rascunho.mp4": "v",
+		"02 Sintaxe/01 b.mp4":   "v",
+		"03 Extra/01 c.mp4":     "v",
+		"03 Extra/notas/x.md":   "a",
+		"capa.jpg":              "img",
+		"apostila.pdf":          "a",
+		"course.yaml": `
+title: Curso de Go
+description: Do zero ao avançado
+author: Gopher
+year: 2025
+cover: capa.jpg
+modules:
+  - folder: 02 Sintaxe
+    name: Sintaxe Básica
+  - folder: 01 Intro
+videos:
+  01 Intro/01 a.mp4: Instalação v1.22
+exclude:
+  - rascunho.mp4
+  - 03 Extra/notas/
+  - capa.jpg
+`,
+	})
+
+	course, err := New(root).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if course.Title != "Curso de Go" || course.Author != "Gopher" || course.Year != 2025 || course.Description == "" {
+		t.Errorf("info do curso = %q %q %d %q", course.Title, course.Author, course.Year, course.Description)
+	}
+	if course.CoverPath != filepath.Join(root, "capa.jpg") {
+		t.Errorf("capa = %q", course.CoverPath)
+	}
+
+	var names []string
+	for _, mod := range course.Modules {
+		names = append(names, mod.Name)
+	}
+	if got := strings.Join(names, ","); got != "Sintaxe Básica,01 Intro,03 Extra" {
+		t.Errorf("módulos = %s", got)
+	}
+
+	first := course.Modules[0].Videos[0]
+	if first.ID != "F001" || first.Module != "Sintaxe Básica" {
+		t.Errorf("primeiro vídeo = %s do módulo %q", first.ID, first.Module)
+	}
+
+	intro := course.Modules[1].Videos
+	if len(intro) != 1 {
+		t.Fatalf("rascunho.mp4 não foi excluído: %d vídeos", len(intro))
+	}
+	if intro[0].CleanTitle() != "Instalação v1.22" || intro[0].ID != "F002" {
+		t.Errorf("vídeo renomeado = %s %q", intro[0].ID, intro[0].CleanTitle())
+	}
+
+	// course.yaml, the cover and the excluded folder are not zipped
+	if len(course.Assets) != 1 || filepath.Base(course.Assets[0]) != "apostila.pdf" {
+		t.Errorf("assets = %v", course.Assets)
+	}
+}
+
+func TestScanRejectsUnknownReferences(t *testing.T) {
+	tests := map[string]string{
+		"course.yaml": "modules:\n  - folder: 99 Nada\n",
+		"course.yml":  "videos:\n  01 Intro/xx.mp4: X\n",
+		"course.json": `{"title": "X", "autor": "campo errado"}`,
+	}
+
+	for file, content := range tests {
+		t.Run(file, func(t *testing.T) {
+			root := t.TempDir()
+			writeFiles(t, root, map[string]string{"01 Intro/01 a.mp4": "v", file: content})
+
+			_, err := New(root).Scan()
+			if !errors.Is(err, ErrInvalidManifest) {
+				t.Fatalf("erro = %v, esperado ErrInvalidManifest", err)
+			}
+		})
+	}
+}
+
+// thumbJPEG is a thumbnail as the older versions wrote next to the videos
+func thumbJPEG(t *testing.T) string {
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, image.NewRGBA(image.Rect(0, 0, 320, 180)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestScanIgnoreRules(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".DS_Store":                   "x",
+		"Thumbs.db":                   "x",
+		"leia.txt":                    "a",
+		"apostila.pdf":                "a",
+		"apostila.pdf.crdownload":     "a",
+		".gopherignore":               "# comentários\n*.txt\n!leia.txt\nbackup/\n",
+		"01 Intro/01 a.mp4":           "v",
+		"01 Intro/01 a_thumb.jpg":     thumbJPEG(t),
+		"01 Intro/01 a-part-002.mp4":  "v",
+		"01 Intro/notas.txt":          "a",
+		"01 Intro/backup/01 a.mp4":    "v",
+		"01 Intro/.gopherignore":      "/extra.zip\n",
+		"01 Intro/extra.zip":          "a",
+		"01 Intro/sub/extra.zip":      "a",
+		"02 Sintaxe/01 b.mp4":         "v",
+		"02 Sintaxe/codigo/main.go":   "a",
+		"02 Sintaxe/codigo/main_test": "a",
+	})
+
+	s := New(root)
+	s.Exclude = []string{"**/codigo/main_test"}
+	course, err := s.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var assets []string
+	for _, path := range course.Assets {
+		rel, _ := filepath.Rel(root, path)
+		assets = append(assets, filepath.ToSlash(rel))
+	}
+	want := "01 Intro/sub/extra.zip,02 Sintaxe/codigo/main.go,apostila.pdf,leia.txt"
+	if got := strings.Join(assets, ","); got != want {
+		t.Errorf("assets = %s\nesperado %s", got, want)
+	}
+
+	if n := len(course.Modules[0].Videos); n != 1 {
+		t.Errorf("vídeos do módulo 1 = %d, esperado 1 (sem a parte e o backup)", n)
+	}
+
+	reasons := map[string]string{}
+	for _, ig := range course.Ignored {
+		rel, _ := filepath.Rel(root, ig.Path)
+		reasons[filepath.ToSlash(rel)] = ig.Reason
+	}
+	for path, reason := range map[string]string{
+		".DS_Store":                   "padrão",
+		"Thumbs.db":                   "padrão",
+		"apostila.pdf.crdownload":     "padrão",
+		"01 Intro/backup":             ".gopherignore:4",
+		"01 Intro/notas.txt":          ".gopherignore:2",
+		"01 Intro/extra.zip":          "01 Intro/.gopherignore:1",
+		"01 Intro/01 a_thumb.jpg":     "sobra",
+		"01 Intro/01 a-part-002.mp4":  "sobra",
+		"02 Sintaxe/codigo/main_test": "--exclude",
+	} {
+		if !strings.HasPrefix(reasons[path], reason) {
+			t.Errorf("%s: motivo = %q, esperado %q...", path, reasons[path], reason)
+		}
+	}
+	if len(reasons) != 9 {
+		t.Errorf("ignorados = %v", reasons)
+	}
+}
+
+func TestScanInclude(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"01 Intro/01 a.mp4":  "v",
+		"01 Intro/01 a.mkv":  "v",
+		"01 Intro/slides.md": "a",
+	})
+
+	s := New(root)
+	s.Include = []string{"*.mp4"}
+	course, err := s.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(course.Modules[0].Videos) != 1 || len(course.Assets) != 0 || len(course.Ignored) != 2 {
+		t.Errorf("vídeos = %d, assets = %v, ignorados = %v", len(course.Modules[0].Videos), course.Assets, course.Ignored)
+	}
+	if course.Ignored[0].Reason != "fora do --include" {
+		t.Errorf("motivo = %q", course.Ignored[0].Reason)
+	}
+
+	s.Exclude = []string{"[x"}
+	if _, err := s.Scan(); !errors.Is(err, ErrInvalidIgnore) {
+		t.Errorf("erro = %v, esperado ErrInvalidIgnore", err)
+	}
+}
+
+func TestScanNestedModules(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"1. Introdução/1. Boas-vindas/01 oi.mp4":      "v",
+		"1. Introdução/1. Boas-vindas/02 suporte.mp4": "v",
+		"1. Introdução/2. Setup/01 go.mp4":            "v",
+		"1. Introdução/2. Setup/extra/01 vscode.mp4":  "v",
+		"1. Introdução/2. Setup/extra/vazio.txt":      "a",
+		"2. Sintaxe/01 tipos.mp4":                     "v",
+		"3. Vazio/notas/nada.txt":                     "a",
+	})
+
+	fullNames := func(course *domain.Course) string {
+		var names []string
+		for _, mod := range course.AllModules() {
+			names = append(names, fmt.Sprintf("%s(%d)", mod.FullName(), len(mod.Videos)))
+		}
+		return strings.Join(names, ", ")
+	}
+
+	course, err := New(root).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1. Introdução(0), 1. Introdução › 1. Boas-vindas(2), 1. Introdução › 2. Setup(1), " +
+		"1. Introdução › 2. Setup › extra(1), 2. Sintaxe(1)"
+	if got := fullNames(course); got != want {
+		t.Errorf("módulos = %s\nesperado %s", got, want)
+	}
+
+	var ids []string
+	for _, vid := range course.Videos() {
+		ids = append(ids, vid.ID+" "+vid.FileName)
+	}
+	if got := strings.Join(ids, ", "); got != "F001 01 oi.mp4, F002 02 suporte.mp4, F003 01 go.mp4, F004 01 vscode.mp4, F005 01 tipos.mp4" {
+		t.Errorf("vídeos = %s", got)
+	}
+	if caption := course.Videos()[3].FormatCaption(); !strings.HasSuffix(caption, "\n1. Introdução › 2. Setup › extra") {
+		t.Errorf("legenda = %q", caption)
+	}
+
+	// With depth 1 the subfolders are flattened, keeping the folder order
+	s := New(root)
+	s.MaxDepth = 1
+	course, err = s.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fullNames(course); got != "1. Introdução(4), 2. Sintaxe(1)" {
+		t.Errorf("módulos com profundidade 1 = %s", got)
+	}
+	if got := course.Videos()[2].FileName; got != "01 go.mp4" {
+		t.Errorf("terceiro vídeo = %s, esperado 01 go.mp4", got)
+	}
+}
+
+func TestScanManifestNestedModules(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"01 Intro/01 a/01 x.mp4": "v",
+		"01 Intro/02 b/01 y.mp4": "v",
+		"02 Fim/01 z.mp4":        "v",
+		"course.yaml":            "modules:\n  - folder: 01 Intro/02 b\n    name: Primeiro\n",
+	})
+
+	course, err := New(root).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	videos := course.Videos()
+	if videos[0].FileName != "01 y.mp4" || videos[0].Module != "01 Intro › Primeiro" || videos[2].FileName != "01 z.mp4" {
+		t.Errorf("ordem = %s (%s), %s, %s", videos[0].FileName, videos[0].Module, videos[1].FileName, videos[2].FileName)
+	}
+}
+
+func TestScanRootVideos(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"00 - Intro.mp4":     "v",
+		"99 - Bônus.mkv":     "v",
+		"leia.pdf":           "a",
+		"01 Básico/01 a.mp4": "v",
+	})
+
+	course, err := New(root).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := course.Modules[0]
+	if first.Name != DefaultRootModule || len(first.Videos) != 2 || len(course.Modules) != 2 {
+		t.Fatalf("módulo da raiz = %q com %d vídeos, %d módulos", first.Name, len(first.Videos), len(course.Modules))
+	}
+	videos := course.Videos()
+	if videos[0].FileName != "00 - Intro.mp4" || videos[0].ID != "F001" || videos[2].ID != "F003" || videos[2].FileName != "01 a.mp4" {
+		t.Errorf("sequência errada: %s=%s, %s=%s", videos[0].ID, videos[0].FileName, videos[2].ID, videos[2].FileName)
+	}
+	if len(course.Assets) != 1 {
+		t.Errorf("assets = %v, os vídeos da raiz não vão para o zip", course.Assets)
+	}
+
+	// The manifest can rename and move it as the folder "."
+	writeFiles(t, root, map[string]string{"course.yaml": "modules:\n  - folder: 01 Básico\n  - folder: .\n    name: Extras\n"})
+	s := New(root)
+	s.RootModule = "Comece Aqui"
+	course, err = s.Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := course.Modules[1]; last.Name != "Extras" || course.Videos()[0].FileName != "01 a.mp4" {
+		t.Errorf("módulos = %s, %s", course.Modules[0].Name, last.Name)
+	}
+}
+
+func TestScanPairsSubtitles(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"01 Intro/01 aula.mp4":       "v",
+		"01 Intro/01 aula.srt":       "s",
+		"01 Intro/01 aula.pt-BR.vtt": "s",
+		"01 Intro/01 aula 2.mp4":     "v",
+		"01 Intro/glossario.srt":     "s",
+	})
+
+	course, err := New(root).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// "01 aula 2" doesn't take the subtitles of "01 aula"
+	other, video := course.Videos()[0], course.Videos()[1]
+	if video.FileName != "01 aula.mp4" || len(video.Subtitles) != 2 || len(other.Subtitles) != 0 {
+		t.Fatalf("legendas de %s = %+v, de %s = %+v", video.FileName, video.Subtitles, other.FileName, other.Subtitles)
+	}
+	if video.Subtitles[0].Lang != "pt-BR" || video.Subtitles[1].Lang != "" {
+		t.Errorf("idiomas = %+v", video.Subtitles)
+	}
+
+	// Without a video with the same name it's only support material
+	if len(course.Assets) != 1 || filepath.Base(course.Assets[0]) != "glossario.srt" {
+		t.Errorf("assets = %v", course.Assets)
+	}
+}
+
+func TestScanAudioLessons(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"01 Podcast/01 abertura.mp3":   "a",
+		"01 Podcast/02 entrevista.mp4": "v",
+		"01 Podcast/03 extra.FLAC":     "a",
+	})
+
+	course, err := New(root).Scan()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, v := range course.Videos() {
+		got = append(got, fmt.Sprintf("%s:%s", v.ID, v.Kind))
+	}
+	if want := "F001:audio,F002:video,F003:audio"; strings.Join(got, ",") != want {
+		t.Errorf("aulas = %v, esperado %s", got, want)
+	}
+	if len(course.Assets) != 0 {
+		t.Errorf("áudios foram para o zip: %v", course.Assets)
+	}
+}
@@ -0,0 +1,111 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// How many previous versions of the state are kept (progress.json.bak.1 is the newest)
+const backupCount = 3
+
+// writeAtomic writes to a temp file in the same folder, syncs it and renames it over
+// the destination. A crash in the middle leaves either the old or the new file, never half.
+func writeAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo temporário: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	// If anything fails the temp file is removed, the original stays untouched
+	ok := false
+	defer func() {
+		if !ok {
+			tmp.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("erro ao escrever %s: %w", tmpName, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("erro no fsync de %s: %w", tmpName, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("erro ao substituir %s: %w", path, err)
+	}
+	ok = true
+
+	// Persist the rename itself. Not supported on every OS, so it's best-effort
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	return nil
+}
+
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.bak.%d", path, n)
+}
+
+// rotateBackups shifts the backups (bak.1 -> bak.2 ...) and saves the current
+// content of the file as bak.1. Nothing is done if the file doesn't exist yet.
+func rotateBackups(path string, perm os.FileMode) error {
+	current, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for n := backupCount - 1; n >= 1; n-- {
+		if _, err := os.Stat(backupPath(path, n)); err == nil {
+			if err := os.Rename(backupPath(path, n), backupPath(path, n+1)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return writeAtomic(backupPath(path, 1), current, perm)
+}
+
+// Copy copies the state file (ex: the one found by FindByFingerprints) with its
+// backups. The original is kept, the match by content may still be wrong. Old
+// backups at the destination are removed first, so the generations of two states
+// never mix, and the state itself is written last: until then the copy isn't used.
+func Copy(from, to string) error {
+	for n := 1; n <= backupCount; n++ {
+		if err := os.Remove(backupPath(to, n)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	for n := backupCount; n >= 0; n-- {
+		src, dst := from, to
+		if n > 0 {
+			src, dst = backupPath(from, n), backupPath(to, n)
+		}
+		data, err := os.ReadFile(src)
+		if os.IsNotExist(err) && n > 0 {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeAtomic(dst, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
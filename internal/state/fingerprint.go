@@ -0,0 +1,72 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Bytes hashed from the beginning and from the end of the file.
+// Hashing everything would take minutes on big videos, the size plus the
+// edges of the file are enough to tell the files of a course apart.
+const fingerprintChunk = 4 * 1024 * 1024
+
+// Separates the fingerprint of the source from the part suffix
+const partSeparator = "#"
+
+// Fingerprint identifies the file by its content, it doesn't change when the
+// file is moved or renamed. Format: <size>-<partial sha256>
+func Fingerprint(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := info.Size()
+
+	h := sha256.New()
+	binary.Write(h, binary.LittleEndian, size)
+
+	if _, err := io.Copy(h, io.LimitReader(file, fingerprintChunk)); err != nil {
+		return "", fmt.Errorf("erro ao ler %s: %w", path, err)
+	}
+
+	// Small files were already read entirely
+	if size > fingerprintChunk {
+		offset := max(size-fingerprintChunk, fingerprintChunk)
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(h, file); err != nil {
+			return "", fmt.Errorf("erro ao ler %s: %w", path, err)
+		}
+	}
+
+	return fmt.Sprintf("%d-%x", size, h.Sum(nil)[:12]), nil
+}
+
+// PartKey is the state key of a part of the source file.
+// A file that wasn't splitted (total 1) uses the fingerprint itself.
+func PartKey(fingerprint string, index, total int) string {
+	if total <= 1 {
+		return fingerprint
+	}
+	return fmt.Sprintf("%s%s%d/%d", fingerprint, partSeparator, index+1, total)
+}
+
+// SubtitleKey is the state key of a subtitle sent as a reply to the video of the
+// fingerprint. Ex: "<fingerprint>#sub:en.srt", or "#sub:.vtt" without language.
+// Tied to the video, the same .srt next to two videos is sent to both, and the
+// extension keeps apart the aula.srt and aula.vtt of one video.
+func SubtitleKey(fingerprint, lang, subtitlePath string) string {
+	return fingerprint + partSeparator + "sub:" + lang + strings.ToLower(filepath.Ext(subtitlePath))
+}
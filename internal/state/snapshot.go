@@ -0,0 +1,117 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Snapshot is a read-only copy of the state of a course
+type Snapshot struct {
+	Location string
+	ChatID   int64
+	Records  map[string]PartRecord
+
+	// Files uploaded by the versions that keyed the state by path
+	LegacyPaths map[string]bool
+}
+
+// ReadSnapshot reads the state of the course without creating or changing any
+// file (no session folder, no migration, no database tables).
+// Returns nil if the course has no state.
+func ReadSnapshot(backend, dbPath, courseName string) (*Snapshot, error) {
+	switch backend {
+	case "", BackendJSON:
+		path := ProgressPath(courseName)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, nil
+		}
+		return ReadJSONSnapshot(path)
+
+	case BackendSQLite:
+		if dbPath == "" {
+			dbPath = DefaultDBPath
+		}
+		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+			return nil, nil
+		}
+		return readSQLiteSnapshot(dbPath, courseName)
+
+	default:
+		return nil, fmt.Errorf("backend de estado desconhecido: %q (use json ou sqlite)", backend)
+	}
+}
+
+// ReadJSONSnapshot reads a JSON state file
+func ReadJSONSnapshot(path string) (*Snapshot, error) {
+	pm, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &Snapshot{
+		Location:    path,
+		ChatID:      pm.Data.TargetChatID,
+		Records:     pm.Records(),
+		LegacyPaths: make(map[string]bool, len(pm.Data.Processed)),
+	}
+	for path, done := range pm.Data.Processed {
+		if done {
+			snap.LegacyPaths[path] = true
+		}
+	}
+	return snap, nil
+}
+
+func readSQLiteSnapshot(dbPath, courseName string) (*Snapshot, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(dbPath, true))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir banco %s: %w", dbPath, err)
+	}
+	defer db.Close()
+
+	s := &SQLiteStore{db: db, dbPath: dbPath, course: courseName}
+
+	err = db.QueryRow(`SELECT id FROM courses WHERE name = ?`, courseName).Scan(&s.courseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler curso: %w", err)
+	}
+
+	return &Snapshot{
+		Location: s.Location(),
+		ChatID:   s.GetChatID(),
+		Records:  s.Records(),
+
+		LegacyPaths: s.legacyPaths(),
+	}, nil
+}
+
+// Parts counts the recorded parts of the source file and in how many parts it
+// was splitted. total is 0 if nothing was recorded.
+func (s *Snapshot) Parts(fingerprint string) (done, total int) {
+	if s == nil {
+		return 0, 0
+	}
+	if _, ok := s.Records[fingerprint]; ok {
+		return 1, 1
+	}
+
+	prefix := fingerprint + partSeparator
+	for key := range s.Records {
+		suffix, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		var index, n int
+		if _, err := fmt.Sscanf(suffix, "%d/%d", &index, &n); err == nil {
+			done++
+			total = max(total, n)
+		}
+	}
+	return done, total
+}
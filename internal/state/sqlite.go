@@ -0,0 +1,510 @@
+package state
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure Go driver, no cgo needed
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS courses (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	name       TEXT    NOT NULL UNIQUE,
+	chat_id    INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS parts (
+	course_id INTEGER NOT NULL REFERENCES courses(id),
+	key       TEXT    NOT NULL,
+	path      TEXT    NOT NULL DEFAULT '',
+	size      INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (course_id, key)
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+	course_id   INTEGER NOT NULL REFERENCES courses(id),
+	part_key    TEXT    NOT NULL,
+	chat_id     INTEGER NOT NULL,
+	message_id  INTEGER NOT NULL,
+	document_id INTEGER NOT NULL DEFAULT 0,
+	access_hash INTEGER NOT NULL DEFAULT 0,
+	caption     TEXT    NOT NULL DEFAULT '',
+	uploaded_at INTEGER NOT NULL,
+	run_id      INTEGER REFERENCES runs(id),
+	PRIMARY KEY (course_id, part_key)
+);
+
+CREATE TABLE IF NOT EXISTS runs (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	course_id   INTEGER NOT NULL REFERENCES courses(id),
+	host        TEXT    NOT NULL DEFAULT '',
+	started_at  INTEGER NOT NULL,
+	finished_at INTEGER,
+	status      TEXT    NOT NULL,
+	error       TEXT    NOT NULL DEFAULT '',
+	uploaded    INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_courses_chat ON courses(chat_id);
+CREATE INDEX IF NOT EXISTS idx_runs_started ON runs(started_at);
+`
+
+// Files uploaded by the versions that keyed the JSON state by path are imported
+// with this prefix on the path, and moved to their real key by IsDone.
+const legacyKeyPrefix = "path:"
+
+// Status of a run in the history
+const (
+	RunRunning = "running"
+	RunSuccess = "success"
+	RunFailed  = "failed"
+)
+
+// SQLiteStore keeps the state of a course in a database shared by every course,
+// with the history of the runs.
+type SQLiteStore struct {
+	db       *sql.DB
+	dbPath   string
+	courseID int64
+	course   string
+
+	mu       sync.Mutex
+	runID    int64
+	uploaded int
+}
+
+// CourseSummary is a course found in the history
+type CourseSummary struct {
+	Name      string
+	ChatID    int64
+	Parts     int
+	CreatedAt time.Time
+}
+
+// RunRecord is an execution of the uploader for a course
+type RunRecord struct {
+	ID         int64
+	Course     string
+	Host       string
+	StartedAt  time.Time
+	FinishedAt time.Time // Zero while running (or if the process died)
+	Status     string
+	Error      string
+	Uploaded   int
+}
+
+func sqliteDSN(dbPath string, readOnly bool) string {
+	if readOnly {
+		// Even read-only, sqlite creates the -wal/-shm files of a WAL database.
+		// They only exist while someone has it open, otherwise it's safe to
+		// open as immutable, which never writes.
+		if _, err := os.Stat(dbPath + "-wal"); os.IsNotExist(err) {
+			return fmt.Sprintf("file:%s?mode=ro&immutable=1", dbPath)
+		}
+		return fmt.Sprintf("file:%s?mode=ro&_pragma=busy_timeout(5000)", dbPath)
+	}
+	return fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)", dbPath)
+}
+
+// OpenSQLiteDB opens (or creates) the database without selecting a course.
+// Only the queries across every course (CoursesByChat, Runs) can be used.
+func OpenSQLiteDB(dbPath string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", sqliteDSN(dbPath, false))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir banco %s: %w", dbPath, err)
+	}
+
+	// A single connection avoids SQLITE_BUSY between our own goroutines
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("erro ao criar tabelas em %s: %w", dbPath, err)
+	}
+
+	return &SQLiteStore{db: db, dbPath: dbPath}, nil
+}
+
+// ReadSQLiteDB opens the database read-only, like ReadSnapshot, for the queries
+// across every course (CoursesByChat, Runs). Nothing is created: it returns nil
+// when the file doesn't exist.
+func ReadSQLiteDB(dbPath string) (*SQLiteStore, error) {
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+	db, err := sql.Open("sqlite", sqliteDSN(dbPath, true))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir banco %s: %w", dbPath, err)
+	}
+	return &SQLiteStore{db: db, dbPath: dbPath}, nil
+}
+
+// OpenSQLiteStore opens (or creates) the database and the course in it.
+// created reports if the course didn't exist in the database yet. A new course
+// starts with the state returned by seed (nil for none), in the same
+// transaction: if the import fails the course isn't created and it's tried again.
+func OpenSQLiteStore(dbPath, courseName string, seed func() (*ProgressManager, error)) (store *SQLiteStore, created bool, err error) {
+	s, err := OpenSQLiteDB(dbPath)
+	if err != nil {
+		return nil, false, err
+	}
+	s.course = courseName
+
+	err = s.db.QueryRow(`SELECT id FROM courses WHERE name = ?`, courseName).Scan(&s.courseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = s.create(seed)
+		created = true
+	} else if err != nil {
+		err = fmt.Errorf("erro ao ler curso: %w", err)
+	}
+	if err != nil {
+		s.db.Close()
+		return nil, false, err
+	}
+
+	return s, created, nil
+}
+
+// create registers the course with the imported state
+func (s *SQLiteStore) create(seed func() (*ProgressManager, error)) error {
+	var pm *ProgressManager
+	if seed != nil {
+		var err error
+		if pm, err = seed(); err != nil {
+			return err
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO courses (name, created_at) VALUES (?, ?)`, s.course, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("erro ao registrar curso: %w", err)
+	}
+	s.courseID, _ = res.LastInsertId()
+
+	if pm != nil {
+		if err := s.importJSON(tx, pm); err != nil {
+			return fmt.Errorf("erro ao importar estado %s: %w", pm.FilePath, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// importJSON copies the uploaded files and the chat of a JSON state. The files
+// of the versions keyed by path keep the path in the key, see legacyKeyPrefix.
+func (s *SQLiteStore) importJSON(tx *sql.Tx, pm *ProgressManager) error {
+	if _, err := tx.Exec(`UPDATE courses SET chat_id = ? WHERE id = ?`, pm.GetChatID(), s.courseID); err != nil {
+		return err
+	}
+	for key, rec := range pm.Data.Files {
+		if err := s.insertPart(tx, key, *rec); err != nil {
+			return err
+		}
+	}
+	for path, done := range pm.Data.Processed {
+		if !done {
+			continue
+		}
+		rec := PartRecord{Path: path}
+		if old, ok := pm.Data.Parts[path]; ok {
+			rec = *old
+			rec.Path = path
+		}
+		if err := s.insertPart(tx, legacyKeyPrefix+path, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsDone checks the file by its key. A file imported from a JSON state keyed by
+// path is found by the legacyPath and moved to the new key.
+func (s *SQLiteStore) IsDone(key, legacyPath string) bool {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM parts WHERE course_id = ? AND key = ?`, s.courseID, key).Scan(&n)
+	if err != nil {
+		log.Printf("⚠️ Erro ao consultar estado: %v", err)
+		return false
+	}
+	if n > 0 || legacyPath == "" {
+		return n > 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		log.Printf("⚠️ Erro ao consultar estado: %v", err)
+		return false
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE parts SET key = ? WHERE course_id = ? AND key = ?`, key, s.courseID, legacyKeyPrefix+legacyPath)
+	if err != nil {
+		log.Printf("⚠️ Erro ao migrar estado de %s: %v", legacyPath, err)
+		return false
+	}
+	if moved, _ := res.RowsAffected(); moved == 0 {
+		return false
+	}
+	if _, err := tx.Exec(`UPDATE messages SET part_key = ? WHERE course_id = ? AND part_key = ?`, key, s.courseID, legacyKeyPrefix+legacyPath); err != nil {
+		log.Printf("⚠️ Erro ao migrar estado de %s: %v", legacyPath, err)
+		return false
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("⚠️ Erro ao migrar estado de %s: %v", legacyPath, err)
+		return false
+	}
+	return true
+}
+
+func (s *SQLiteStore) MarkAsUploaded(key string, rec PartRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.insertPart(tx, key, rec); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.uploaded++
+	return nil
+}
+
+// insertPart saves the part and the message that holds it
+func (s *SQLiteStore) insertPart(tx *sql.Tx, key string, rec PartRecord) error {
+	_, err := tx.Exec(`INSERT OR REPLACE INTO parts (course_id, key, path, size) VALUES (?, ?, ?, ?)`,
+		s.courseID, key, rec.Path, rec.Size)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar parte: %w", err)
+	}
+
+	var runID any
+	if s.runID != 0 {
+		runID = s.runID
+	}
+
+	_, err = tx.Exec(`INSERT OR REPLACE INTO messages
+		(course_id, part_key, chat_id, message_id, document_id, access_hash, caption, uploaded_at, run_id)
+		VALUES (?, ?, (SELECT chat_id FROM courses WHERE id = ?), ?, ?, ?, ?, ?, ?)`,
+		s.courseID, key, s.courseID, rec.MessageID, rec.DocumentID, rec.AccessHash,
+		rec.Caption, rec.UploadedAt.Unix(), runID)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar mensagem: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetPart(key string) (PartRecord, bool) {
+	var rec PartRecord
+	var uploadedAt sql.NullInt64
+	var messageID, documentID, accessHash sql.NullInt64
+	var caption sql.NullString
+
+	err := s.db.QueryRow(`SELECT p.path, p.size, m.message_id, m.document_id, m.access_hash, m.caption, m.uploaded_at
+		FROM parts p LEFT JOIN messages m ON m.course_id = p.course_id AND m.part_key = p.key
+		WHERE p.course_id = ? AND p.key = ?`, s.courseID, key).
+		Scan(&rec.Path, &rec.Size, &messageID, &documentID, &accessHash, &caption, &uploadedAt)
+	if err != nil {
+		return PartRecord{}, false
+	}
+
+	rec.MessageID = int(messageID.Int64)
+	rec.DocumentID = documentID.Int64
+	rec.AccessHash = accessHash.Int64
+	rec.Caption = caption.String
+	if uploadedAt.Valid {
+		rec.UploadedAt = time.Unix(uploadedAt.Int64, 0)
+	}
+	return rec, true
+}
+
+func (s *SQLiteStore) GetChatID() int64 {
+	var chatID int64
+	s.db.QueryRow(`SELECT chat_id FROM courses WHERE id = ?`, s.courseID).Scan(&chatID)
+	return chatID
+}
+
+func (s *SQLiteStore) SetChatID(id int64) error {
+	_, err := s.db.Exec(`UPDATE courses SET chat_id = ? WHERE id = ?`, id, s.courseID)
+	return err
+}
+
+// legacyPaths lists the files imported from a JSON state keyed by path, not seen again yet
+func (s *SQLiteStore) legacyPaths() map[string]bool {
+	paths := make(map[string]bool)
+	rows, err := s.db.Query(`SELECT key FROM parts WHERE course_id = ? AND key LIKE ?`, s.courseID, legacyKeyPrefix+"%")
+	if err != nil {
+		log.Printf("⚠️ Erro ao consultar estado: %v", err)
+		return paths
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		if rows.Scan(&key) == nil {
+			paths[strings.TrimPrefix(key, legacyKeyPrefix)] = true
+		}
+	}
+	return paths
+}
+
+func (s *SQLiteStore) Records() map[string]PartRecord {
+	records := make(map[string]PartRecord)
+
+	rows, err := s.db.Query(`SELECT key FROM parts WHERE course_id = ?`, s.courseID)
+	if err != nil {
+		log.Printf("⚠️ Erro ao consultar estado: %v", err)
+		return records
+	}
+	var keys []string
+	for rows.Next() {
+		var key string
+		// The legacy entries are found only by their path, like in the JSON state
+		if rows.Scan(&key) == nil && !strings.HasPrefix(key, legacyKeyPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	rows.Close()
+
+	for _, key := range keys {
+		if rec, ok := s.GetPart(key); ok {
+			records[key] = rec
+		}
+	}
+	return records
+}
+
+// Reset forgets the uploaded files and the chat, the run history is kept
+func (s *SQLiteStore) Reset() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, q := range []string{
+		`DELETE FROM messages WHERE course_id = ?`,
+		`DELETE FROM parts WHERE course_id = ?`,
+		`UPDATE courses SET chat_id = 0 WHERE id = ?`,
+	} {
+		if _, err := tx.Exec(q, s.courseID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) StartRun() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	host, _ := os.Hostname()
+	res, err := s.db.Exec(`INSERT INTO runs (course_id, host, started_at, status) VALUES (?, ?, ?, ?)`,
+		s.courseID, host, time.Now().Unix(), RunRunning)
+	if err != nil {
+		return fmt.Errorf("erro ao registrar execução: %w", err)
+	}
+	s.runID, _ = res.LastInsertId()
+	s.uploaded = 0
+	return nil
+}
+
+func (s *SQLiteStore) FinishRun(runErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.runID == 0 {
+		return nil
+	}
+
+	status, msg := RunSuccess, ""
+	if runErr != nil {
+		status, msg = RunFailed, runErr.Error()
+	}
+
+	_, err := s.db.Exec(`UPDATE runs SET finished_at = ?, status = ?, error = ?, uploaded = ? WHERE id = ?`,
+		time.Now().Unix(), status, msg, s.uploaded, s.runID)
+	s.runID = 0
+	return err
+}
+
+func (s *SQLiteStore) Location() string {
+	return fmt.Sprintf("%s (curso: %s)", s.dbPath, s.course)
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// CoursesByChat lists the courses of the database uploaded to the chat
+func (s *SQLiteStore) CoursesByChat(chatID int64) ([]CourseSummary, error) {
+	rows, err := s.db.Query(`SELECT c.name, c.chat_id, c.created_at,
+			(SELECT COUNT(*) FROM parts p WHERE p.course_id = c.id)
+		FROM courses c WHERE c.chat_id = ? ORDER BY c.created_at`, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var courses []CourseSummary
+	for rows.Next() {
+		var c CourseSummary
+		var createdAt int64
+		if err := rows.Scan(&c.Name, &c.ChatID, &createdAt, &c.Parts); err != nil {
+			return nil, err
+		}
+		c.CreatedAt = time.Unix(createdAt, 0)
+		courses = append(courses, c)
+	}
+	return courses, rows.Err()
+}
+
+// Runs lists the runs of every course started after since.
+// An empty status returns all of them, otherwise only the ones with the status.
+func (s *SQLiteStore) Runs(since time.Time, status string) ([]RunRecord, error) {
+	rows, err := s.db.Query(`SELECT r.id, c.name, r.host, r.started_at, r.finished_at, r.status, r.error, r.uploaded
+		FROM runs r JOIN courses c ON c.id = r.course_id
+		WHERE r.started_at >= ? AND (? = '' OR r.status = ?)
+		ORDER BY r.started_at DESC`, since.Unix(), status, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []RunRecord
+	for rows.Next() {
+		var r RunRecord
+		var startedAt int64
+		var finishedAt sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.Course, &r.Host, &startedAt, &finishedAt, &r.Status, &r.Error, &r.Uploaded); err != nil {
+			return nil, err
+		}
+		r.StartedAt = time.Unix(startedAt, 0)
+		if finishedAt.Valid {
+			r.FinishedAt = time.Unix(finishedAt.Int64, 0)
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
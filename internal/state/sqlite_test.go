@@ -0,0 +1,119 @@
+package state
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T, dbPath string, seed func() (*ProgressManager, error)) *SQLiteStore {
+	t.Helper()
+	s, _, err := OpenSQLiteStore(dbPath, "Curso", seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "gophergram.db")
+	s := openTestStore(t, dbPath, nil)
+
+	if err := s.SetChatID(-100123); err != nil {
+		t.Fatal(err)
+	}
+	rec := PartRecord{
+		Path:       "/curso/aula.mp4",
+		MessageID:  42,
+		DocumentID: 7,
+		AccessHash: 9,
+		Size:       1024,
+		UploadedAt: time.Unix(1700000000, 0),
+		Caption:    "#F001 Aula",
+	}
+	key := PartKey("fp", 0, 2)
+	if err := s.MarkAsUploaded(key, rec); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// Everything is read back from the database
+	s = openTestStore(t, dbPath, nil)
+	if s.GetChatID() != -100123 {
+		t.Errorf("chat = %d", s.GetChatID())
+	}
+	if !s.IsDone(key, "") || s.IsDone(PartKey("fp", 1, 2), "") {
+		t.Error("IsDone errado para as partes")
+	}
+	got, ok := s.GetPart(key)
+	if !ok || got != rec {
+		t.Errorf("GetPart = %+v, esperado %+v", got, rec)
+	}
+	if records := s.Records(); len(records) != 1 || records[key] != rec {
+		t.Errorf("Records = %+v", records)
+	}
+}
+
+func TestSQLiteImportsJSONState(t *testing.T) {
+	pm, err := Load(filepath.Join(t.TempDir(), "progress.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm.Data.TargetChatID = -100999
+	pm.Data.Files["fp"] = &PartRecord{Path: "/curso/a.mp4", MessageID: 10}
+	// Version 1: keyed by path, with and without the message
+	pm.Data.Processed = map[string]bool{"/curso/b.mp4": true, "/curso/c.mp4": true}
+	pm.Data.Parts = map[string]*PartRecord{"/curso/b.mp4": {MessageID: 11}}
+
+	s := openTestStore(t, filepath.Join(t.TempDir(), "gophergram.db"), func() (*ProgressManager, error) { return pm, nil })
+
+	if s.GetChatID() != -100999 {
+		t.Errorf("chat = %d", s.GetChatID())
+	}
+	if !s.IsDone("fp", "/curso/a.mp4") {
+		t.Error("arquivo da versão 2 não importado")
+	}
+	if len(s.Records()) != 1 {
+		t.Errorf("as entradas por caminho não devem aparecer nos registros: %+v", s.Records())
+	}
+
+	// Found by the path, then moved to the key
+	if !s.IsDone("fp-b", "/curso/b.mp4") || !s.IsDone("fp-c", "/curso/c.mp4") {
+		t.Fatal("arquivos da versão 1 não importados")
+	}
+	if rec, ok := s.GetPart("fp-b"); !ok || rec.MessageID != 11 {
+		t.Errorf("mensagem do arquivo da versão 1 = %+v", rec)
+	}
+	if !s.IsDone("fp-b", "") || len(s.Records()) != 3 {
+		t.Errorf("entrada não migrada para a chave: %+v", s.Records())
+	}
+	if s.IsDone("fp-d", "/curso/d.mp4") {
+		t.Error("arquivo nunca enviado marcado como feito")
+	}
+}
+
+func TestSQLiteFailedImportIsRetried(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "gophergram.db")
+
+	_, _, err := OpenSQLiteStore(dbPath, "Curso", func() (*ProgressManager, error) {
+		return nil, errors.New("estado corrompido")
+	})
+	if err == nil {
+		t.Fatal("erro da importação ignorado")
+	}
+
+	if exists, err := Exists(BackendSQLite, dbPath, "Curso"); err != nil || exists {
+		t.Fatalf("curso criado apesar da importação falhar (exists %v, err %v)", exists, err)
+	}
+
+	imported := false
+	openTestStore(t, dbPath, func() (*ProgressManager, error) {
+		imported = true
+		return nil, nil
+	})
+	if !imported {
+		t.Error("a importação não foi tentada de novo")
+	}
+}
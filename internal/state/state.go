@@ -6,18 +6,47 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"sync"
+	"time"
 )
 
+// SchemaVersion of the state file. Files without version are from the
+// versions that keyed everything by path (1).
+const SchemaVersion = 2
+
 type StateData struct {
-	TargetChatID int64           `json:"target_chat_id"`
-	Processed    map[string]bool `json:"processed"`
+	Version      int   `json:"version"`
+	TargetChatID int64 `json:"target_chat_id"`
+
+	// Uploaded files keyed by content fingerprint (see PartKey)
+	Files map[string]*PartRecord `json:"files"`
+
+	// Legacy: older versions keyed the uploaded files by their path.
+	// The entries are moved to Files the first time the file is seen again.
+	Processed map[string]bool        `json:"processed,omitempty"`
+	Parts     map[string]*PartRecord `json:"parts,omitempty"`
+}
+
+// PartRecord is the channel message that holds an uploaded file
+type PartRecord struct {
+	Path       string    `json:"path,omitempty"` // Last known path, informative only
+	MessageID  int       `json:"message_id"`
+	DocumentID int64     `json:"document_id,omitempty"`
+	AccessHash int64     `json:"access_hash,omitempty"`
+	Size       int64     `json:"size"`
+	UploadedAt time.Time `json:"uploaded_at"`
+	Caption    string    `json:"caption"`
 }
 
 type ProgressManager struct {
 	FilePath string
 	Data     *StateData
 	mu       sync.Mutex
+
+	// The backups are rotated on the first save of the run only, so bak.N are
+	// the states of the previous runs (and of the previous schema after an upgrade)
+	rotated bool
 }
 
 func Load(filename string) (*ProgressManager, error) {
@@ -25,7 +54,7 @@ func Load(filename string) (*ProgressManager, error) {
 		FilePath: filename,
 		Data: &StateData{
 			TargetChatID: 0,
-			Processed:    make(map[string]bool),
+			Files:        make(map[string]*PartRecord),
 		},
 	}
 
@@ -34,48 +63,150 @@ func Load(filename string) (*ProgressManager, error) {
 		if err != nil {
 			return nil, err
 		}
+
+		// Never start from zero on a broken file, we would upload the entire course again
 		if err := json.Unmarshal(content, pm.Data); err != nil {
-			return pm, nil
+			return nil, fmt.Errorf("estado corrompido em %s: %w (restaure um backup %s.bak.N ou apague o arquivo para recomeçar)",
+				filename, err, filename)
+		}
+
+		if pm.Data.Version > SchemaVersion {
+			return nil, fmt.Errorf("estado %s tem versão %d, mais nova que a suportada (%d). Atualize o GopherGram",
+				filename, pm.Data.Version, SchemaVersion)
 		}
 
-		if pm.Data.Processed == nil {
-			pm.Data.Processed = make(map[string]bool)
+		if pm.Data.Files == nil {
+			pm.Data.Files = make(map[string]*PartRecord)
 		}
 	}
 
 	return pm, nil
 }
 
-func LoadProgressContent(courseName string) (*ProgressManager, error) {
-	// Slugify the file name
-	re := regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
-	safeName := re.ReplaceAllString(courseName, "_")
+// Slugify the file name
+var slugRe = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+const sessionDir = "session"
+
+// ProgressPath is where the state of the course is saved
+func ProgressPath(courseName string) string {
+	safeName := slugRe.ReplaceAllString(courseName, "_")
+	return filepath.Join(sessionDir, fmt.Sprintf("progress_%s.json", safeName))
+}
 
-	filename := fmt.Sprintf("progress_%s.json", safeName)
-	path := filepath.Join("session", filename)
+// LockPath is the lock file that prevents two uploaders working on the same course
+func LockPath(courseName string) string {
+	safeName := slugRe.ReplaceAllString(courseName, "_")
+	return filepath.Join(sessionDir, fmt.Sprintf("progress_%s.lock", safeName))
+}
 
-	//
-	if err := os.MkdirAll("session", 0700); err != nil {
+func LoadProgressContent(courseName string) (*ProgressManager, error) {
+	if err := os.MkdirAll(sessionDir, 0700); err != nil {
 		return nil, fmt.Errorf("erro pasta session: %w", err)
 	}
 
-	return Load(path)
+	return Load(ProgressPath(courseName))
+}
+
+// FindByFingerprints looks in the session folder for the state of a course whose
+// folder was renamed or moved, given the fingerprints of its videos. A state is
+// only taken when it has most of them, one shared video (the same intro in two
+// courses) isn't enough. Returns an empty string if none is found.
+func FindByFingerprints(fingerprints []string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(sessionDir, "progress_*.json"))
+	if err != nil {
+		return "", err
+	}
+
+	best, bestCount := "", 0
+	for _, path := range matches {
+		pm, err := Load(path)
+		if err != nil {
+			continue
+		}
+		count := 0
+		for _, fp := range fingerprints {
+			if pm.hasFingerprint(fp) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = path, count
+		}
+	}
+
+	if bestCount*2 <= len(fingerprints) {
+		return "", nil
+	}
+	return best, nil
+}
+
+// hasFingerprint tells if the file or any of its parts or subtitles is in the state
+func (pm *ProgressManager) hasFingerprint(fingerprint string) bool {
+	for key := range pm.Data.Files {
+		if key == fingerprint || strings.HasPrefix(key, fingerprint+partSeparator) {
+			return true
+		}
+	}
+	return false
+}
+
+// NameOf is the course name of a state file, the inverse of ProgressPath
+func NameOf(progressPath string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(filepath.Base(progressPath), "progress_"), ".json")
 }
 
-func (pm *ProgressManager) IsDone(filePath string) bool {
+// IsDone checks the file by its key. The legacyPath is the path used as key by
+// older versions, when it's found there the entry is migrated to the new key.
+func (pm *ProgressManager) IsDone(key, legacyPath string) bool {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
-	return pm.Data.Processed[filePath]
+
+	if _, ok := pm.Data.Files[key]; ok {
+		return true
+	}
+
+	if !pm.Data.Processed[legacyPath] {
+		return false
+	}
+
+	rec := &PartRecord{Path: legacyPath}
+	if old, ok := pm.Data.Parts[legacyPath]; ok {
+		rec = old
+		rec.Path = legacyPath
+	}
+	pm.Data.Files[key] = rec
+	delete(pm.Data.Processed, legacyPath)
+	delete(pm.Data.Parts, legacyPath)
+
+	if err := pm.saveToDisk(); err != nil {
+		fmt.Printf("⚠️ Erro ao migrar estado de %s: %v\n", filepath.Base(legacyPath), err)
+	}
+	return true
 }
 
-func (pm *ProgressManager) MarkAsDone(filePath string) error {
+// MarkAsUploaded marks the file as done, keeping the message that holds it
+func (pm *ProgressManager) MarkAsUploaded(key string, rec PartRecord) error {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
-	pm.Data.Processed[filePath] = true
+	pm.Data.Files[key] = &rec
 	return pm.saveToDisk()
 }
 
+// GetPart returns the message of an uploaded file, if we have it recorded.
+// Files uploaded by older versions may have no message.
+func (pm *ProgressManager) GetPart(key string) (PartRecord, bool) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	rec, ok := pm.Data.Files[key]
+	if !ok {
+		return PartRecord{}, false
+	}
+	return *rec, true
+}
+
 func (pm *ProgressManager) GetChatID() int64 {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
@@ -90,10 +221,56 @@ func (pm *ProgressManager) SetChatID(id int64) error {
 	return pm.saveToDisk()
 }
 
+func (pm *ProgressManager) Records() map[string]PartRecord {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	records := make(map[string]PartRecord, len(pm.Data.Files))
+	for key, rec := range pm.Data.Files {
+		records[key] = *rec
+	}
+	return records
+}
+
+// Reset removes the state file and its backups
+func (pm *ProgressManager) Reset() error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.Data = &StateData{Files: make(map[string]*PartRecord)}
+
+	paths := []string{pm.FilePath}
+	for n := 1; n <= backupCount; n++ {
+		paths = append(paths, backupPath(pm.FilePath, n))
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// The JSON state keeps no run history
+func (pm *ProgressManager) StartRun() error              { return nil }
+func (pm *ProgressManager) FinishRun(runErr error) error { return nil }
+
+func (pm *ProgressManager) Location() string { return pm.FilePath }
+func (pm *ProgressManager) Close() error     { return nil }
+
 func (pm *ProgressManager) saveToDisk() error {
+	pm.Data.Version = SchemaVersion
+
 	bytes, err := json.MarshalIndent(pm.Data, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(pm.FilePath, bytes, 0644)
+
+	if !pm.rotated {
+		if err := rotateBackups(pm.FilePath, 0644); err != nil {
+			return fmt.Errorf("erro ao salvar backup do estado: %w", err)
+		}
+		pm.rotated = true
+	}
+	return writeAtomic(pm.FilePath, bytes, 0644)
 }
@@ -0,0 +1,71 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveRotatesBackupsOncePerRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+
+	// Each Load is a run that saves a few times
+	for run := 1; run <= 2; run++ {
+		pm, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			if err := pm.SetChatID(int64(run*100 + i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// bak.1 is the end of the first run, there was nothing before it
+	pm, err := Load(backupPath(path, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm.GetChatID() != 104 {
+		t.Errorf("bak.1 com o chat %d, esperado o fim da execução anterior (104)", pm.GetChatID())
+	}
+	if _, err := os.Stat(backupPath(path, 2)); !os.IsNotExist(err) {
+		t.Errorf("bak.2 criado por saves da mesma execução: %v", err)
+	}
+}
+
+func TestCopyKeepsBackups(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "progress_Curso_antigo.json")
+	to := filepath.Join(dir, "progress_Curso.json")
+
+	for _, path := range []string{from, backupPath(from, 1), backupPath(from, 2), backupPath(to, 3)} {
+		if err := os.WriteFile(path, []byte(filepath.Base(path)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Copy(from, to); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]string{
+		to:                "progress_Curso_antigo.json",
+		backupPath(to, 1): "progress_Curso_antigo.json.bak.1",
+		backupPath(to, 2): "progress_Curso_antigo.json.bak.2",
+	} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != want {
+			t.Errorf("%s = %q (%v), esperado %q", filepath.Base(path), data, err, want)
+		}
+	}
+	if _, err := os.Stat(backupPath(to, 3)); !os.IsNotExist(err) {
+		t.Errorf("backup antigo do destino continua existindo: %v", err)
+	}
+	// The original stays, the match by content may be wrong
+	for _, kept := range []string{from, backupPath(from, 1), backupPath(from, 2)} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Errorf("%s apagado: %v", filepath.Base(kept), err)
+		}
+	}
+}
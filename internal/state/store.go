@@ -0,0 +1,152 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ProgressStore persists what was already uploaded for a course
+type ProgressStore interface {
+	IsDone(key, legacyPath string) bool
+	MarkAsUploaded(key string, rec PartRecord) error
+	GetPart(key string) (PartRecord, bool)
+	GetChatID() int64
+	SetChatID(id int64) error
+
+	// Every uploaded file, keyed like IsDone
+	Records() map[string]PartRecord
+	// Forget everything uploaded for the course
+	Reset() error
+
+	// Run history, StartRun must be called before the uploads
+	StartRun() error
+	FinishRun(runErr error) error
+
+	// Where the state lives, to show to the user
+	Location() string
+	Close() error
+}
+
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// Default database of the sqlite backend, shared by every course
+const DefaultDBPath = sessionDir + "/gophergram.db"
+
+var (
+	_ ProgressStore = (*ProgressManager)(nil)
+	_ ProgressStore = (*SQLiteStore)(nil)
+)
+
+// OpenStore opens the state of the course in the chosen backend.
+// A course opened for the first time in sqlite imports its JSON state, if any.
+func OpenStore(backend, dbPath, courseName string) (ProgressStore, error) {
+	switch backend {
+	case "", BackendJSON:
+		return LoadProgressContent(courseName)
+
+	case BackendSQLite:
+		if err := os.MkdirAll(sessionDir, 0700); err != nil {
+			return nil, fmt.Errorf("erro pasta session: %w", err)
+		}
+		if dbPath == "" {
+			dbPath = DefaultDBPath
+		}
+
+		store, _, err := OpenSQLiteStore(dbPath, courseName, func() (*ProgressManager, error) {
+			if _, err := os.Stat(ProgressPath(courseName)); err != nil {
+				return nil, nil
+			}
+			pm, err := Load(ProgressPath(courseName))
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("📥 Importando estado JSON %s para o banco...\n", pm.FilePath)
+			return pm, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+
+	default:
+		return nil, fmt.Errorf("backend de estado desconhecido: %q (use json ou sqlite)", backend)
+	}
+}
+
+// Exists reports if the course has a state in the backend, without creating it
+func Exists(backend, dbPath, courseName string) (bool, error) {
+	switch backend {
+	case "", BackendJSON:
+		_, err := os.Stat(ProgressPath(courseName))
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return err == nil, err
+
+	case BackendSQLite:
+		if dbPath == "" {
+			dbPath = DefaultDBPath
+		}
+		db, err := ReadSQLiteDB(dbPath)
+		if err != nil || db == nil {
+			return false, err
+		}
+		defer db.Close()
+
+		var n int
+		err = db.db.QueryRow(`SELECT COUNT(*) FROM courses WHERE name = ?`, courseName).Scan(&n)
+		return n > 0, err
+
+	default:
+		return false, fmt.Errorf("backend de estado desconhecido: %q (use json ou sqlite)", backend)
+	}
+}
+
+// FirstPart finds the record of the first part of the source file, whatever
+// the number of parts it was splitted in.
+func FirstPart(records map[string]PartRecord, fingerprint string) (PartRecord, bool) {
+	if rec, ok := records[fingerprint]; ok {
+		return rec, true
+	}
+	for key, rec := range records {
+		if strings.HasPrefix(key, fingerprint+partSeparator+"1/") {
+			return rec, true
+		}
+	}
+	return PartRecord{}, false
+}
+
+// UploadedParts reports if every part of the source file was uploaded, and in
+// how many parts it was splitted.
+func UploadedParts(records map[string]PartRecord, fingerprint string) (int, bool) {
+	if _, ok := records[fingerprint]; ok {
+		return 1, true
+	}
+
+	total := 0
+	for key := range records {
+		rest, ok := strings.CutPrefix(key, fingerprint+partSeparator)
+		if !ok {
+			continue
+		}
+		if _, n, found := strings.Cut(rest, "/"); found {
+			total, _ = strconv.Atoi(n)
+			break
+		}
+	}
+	if total < 1 {
+		return 0, false
+	}
+
+	for i := 0; i < total; i++ {
+		if _, ok := records[PartKey(fingerprint, i, total)]; !ok {
+			return total, false
+		}
+	}
+	return total, true
+}
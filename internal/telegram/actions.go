@@ -3,8 +3,10 @@ package telegram
 import (
 	"context"
 	"fmt"
+	"mime"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/FolcloreX/GopherGram/internal/processor"
@@ -45,7 +47,7 @@ func (c *Client) uploadWithRetry(
 		fmt.Printf("\n%s %s (Tentativa %d/%d)\n", label, fileName, attempt, maxRetries)
 
 		bar := progressbar.DefaultBytes(info.Size(), label)
-		u := c.uploader.WithProgress(&progressWrapper{bar: bar})
+		u := c.newUploader().WithProgress(&progressWrapper{bar: bar})
 
 		uploadCtx, cancel := context.WithTimeout(ctx, timeout)
 
@@ -84,14 +86,46 @@ func (c *Client) uploadWithRetry(
 	return nil, fmt.Errorf("falha desconhecida no loop de upload")
 }
 
+// SentMessage is what we keep of a media posted in the target chat,
+// enough to link, edit or forward it later without uploading again.
+type SentMessage struct {
+	MessageID  int
+	DocumentID int64
+	AccessHash int64
+	Size       int64
+	Date       time.Time
+}
+
 func (c *Client) UploadAndSendVideo(
 	ctx context.Context,
 	filePath string,
 	caption string,
 	meta *processor.VideoMeta,
-) error {
+) (*SentMessage, error) {
+	media, err := c.UploadVideo(ctx, filePath, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Print("📨 Enviando mensagem do vídeo... ")
+	sent, err := c.SendMedia(ctx, media, caption)
+	if err != nil {
+		return nil, fmt.Errorf("erro envio vídeo: %w", err)
+	}
+
+	fmt.Println("✅ Vídeo enviado com sucesso")
+	return sent, nil
+}
+
+// UploadVideo uploads the video and its thumbnail, returning the media ready to be sent.
+// It's safe to call concurrently, the message itself is sent by SendMedia.
+func (c *Client) UploadVideo(
+	ctx context.Context,
+	filePath string,
+	meta *processor.VideoMeta,
+) (tg.InputMediaClass, error) {
 	if c.TargetPeer == nil {
-		return fmt.Errorf("TargetPeer nulo (rode CheckChatAccess)")
+		return nil, fmt.Errorf("TargetPeer nulo (rode CheckChatAccess)")
 	}
 
 	fileName := filepath.Base(filePath)
@@ -100,13 +134,13 @@ func (c *Client) UploadAndSendVideo(
 	// File upload with retry
 	videoUpload, err := c.uploadWithRetry(ctx, filePath, "⬆️  Video", 120*time.Minute)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Upload of the thumbanil (Best-effort)
 	var thumbUpload tg.InputFileClass
 	if meta.ThumbPath != "" {
-		fmt.Print("🖼 Enviando thumbnail... ")
+		fmt.Printf("🖼 Enviando thumbnail de %s... ", fileName)
 		if t, err := c.uploader.FromPath(ctx, meta.ThumbPath); err == nil {
 			thumbUpload = t
 			fmt.Println("OK")
@@ -115,10 +149,14 @@ func (c *Client) UploadAndSendVideo(
 		}
 	}
 
-	// 3. Montagem dos Atributos
+	mimeType := meta.MimeType
+	if mimeType == "" {
+		mimeType = processor.VideoMimeType(fileName)
+	}
+
 	attrs := []tg.DocumentAttributeClass{
 		&tg.DocumentAttributeVideo{
-			SupportsStreaming: true,
+			SupportsStreaming: meta.Streamable,
 			Duration:          float64(meta.Duration),
 			W:                 meta.Width,
 			H:                 meta.Height,
@@ -128,7 +166,7 @@ func (c *Client) UploadAndSendVideo(
 
 	inputMedia := &tg.InputMediaUploadedDocument{
 		File:       videoUpload,
-		MimeType:   "video/mp4",
+		MimeType:   mimeType,
 		Attributes: attrs,
 		ForceFile:  false,
 	}
@@ -137,32 +175,84 @@ func (c *Client) UploadAndSendVideo(
 		inputMedia.Thumb = thumbUpload
 	}
 
-	fmt.Print("📨 Enviando mensagem do vídeo... ")
-	_, err = c.sender.
-		To(c.TargetPeer).
-		Media(ctx, message.Media(inputMedia, html.String(nil, caption)))
+	return inputMedia, nil
+}
+
+// UploadAudio uploads an audio lesson and its cover, returning the media ready to be sent.
+// It's sent with DocumentAttributeAudio so it plays in the audio player of Telegram.
+func (c *Client) UploadAudio(
+	ctx context.Context,
+	filePath string,
+	meta *processor.AudioMeta,
+) (tg.InputMediaClass, error) {
+	if c.TargetPeer == nil {
+		return nil, fmt.Errorf("TargetPeer nulo (rode CheckChatAccess)")
+	}
+
+	fileName := filepath.Base(filePath)
+	fmt.Printf("\n🎧 Enviando áudio: %s\n", fileName)
 
-	// Treating floodAwait
+	audioUpload, err := c.uploadWithRetry(ctx, filePath, "⬆️  Audio", 60*time.Minute)
 	if err != nil {
-		if d, ok := tgerr.AsFloodWait(err); ok {
-			fmt.Printf("\n⏳ FloodWait no envio (%v). Aguardando...\n", d)
-			time.Sleep(d + 1*time.Second)
-			return c.UploadAndSendVideo(ctx, filePath, caption, meta) // Recursive Retry
+		return nil, err
+	}
+
+	// Upload of the cover (Best-effort)
+	var coverUpload tg.InputFileClass
+	if meta.CoverPath != "" {
+		fmt.Printf("🖼 Enviando capa de %s... ", fileName)
+		if t, err := c.uploader.FromPath(ctx, meta.CoverPath); err == nil {
+			coverUpload = t
+			fmt.Println("OK")
+		} else {
+			fmt.Println("Falhou (ignorando)")
 		}
-		return fmt.Errorf("erro envio vídeo: %w", err)
 	}
 
-	fmt.Println("✅ Vídeo enviado com sucesso")
-	return nil
+	attrs := []tg.DocumentAttributeClass{
+		&tg.DocumentAttributeAudio{
+			Duration:  meta.Duration,
+			Title:     meta.Title,
+			Performer: meta.Performer,
+		},
+		&tg.DocumentAttributeFilename{FileName: fileName},
+	}
+
+	inputMedia := &tg.InputMediaUploadedDocument{
+		File:       audioUpload,
+		MimeType:   AudioMimeType(fileName),
+		Attributes: attrs,
+		ForceFile:  false,
+	}
+
+	if coverUpload != nil {
+		inputMedia.Thumb = coverUpload
+	}
+
+	return inputMedia, nil
+}
+
+// AudioMimeType is the type of the audio formats accepted by domain.IsAudio
+func AudioMimeType(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".m4a":
+		return "audio/mp4"
+	case ".ogg":
+		return "audio/ogg"
+	case ".flac":
+		return "audio/flac"
+	default:
+		return "audio/mpeg"
+	}
 }
 
 func (c *Client) UploadAndSendDocument(
 	ctx context.Context,
 	filePath string,
 	caption string,
-) error {
+) (*SentMessage, error) {
 	if c.TargetPeer == nil {
-		return fmt.Errorf("TargetPeer nulo")
+		return nil, fmt.Errorf("TargetPeer nulo")
 	}
 
 	fileName := filepath.Base(filePath)
@@ -170,7 +260,7 @@ func (c *Client) UploadAndSendDocument(
 
 	fileUpload, err := c.uploadWithRetry(ctx, filePath, "⬆️  Doc  ", 60*time.Minute)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	attrs := []tg.DocumentAttributeClass{
@@ -185,21 +275,91 @@ func (c *Client) UploadAndSendDocument(
 	}
 
 	fmt.Print("📨 Enviando mensagem do documento... ")
-	_, err = c.sender.
-		To(c.TargetPeer).
-		Media(ctx, message.Media(inputMedia, html.String(nil, caption)))
+	sent, err := c.SendMedia(ctx, inputMedia, caption)
+	if err != nil {
+		return nil, fmt.Errorf("erro envio doc: %w", err)
+	}
+
+	fmt.Println("✅ Documento enviado com sucesso")
+	return sent, nil
+}
+
+// SendMedia posts an already uploaded media in the target chat, waiting on FloodWait.
+// The uploaded file stays valid on the server, so retrying doesn't upload it again.
+func (c *Client) SendMedia(ctx context.Context, media tg.InputMediaClass, caption string) (*SentMessage, error) {
+	return c.sendMedia(ctx, 0, media, caption)
+}
+
+// ReplyWithDocument uploads the file and sends it as a document replying to the
+// message (the subtitles of a video)
+func (c *Client) ReplyWithDocument(ctx context.Context, replyTo int, filePath string, caption string) (*SentMessage, error) {
+	if c.TargetPeer == nil {
+		return nil, fmt.Errorf("TargetPeer nulo")
+	}
 
+	fileName := filepath.Base(filePath)
+	fileUpload, err := c.uploadWithRetry(ctx, filePath, "⬆️  Doc  ", 10*time.Minute)
 	if err != nil {
-		if d, ok := tgerr.AsFloodWait(err); ok {
-			fmt.Printf("\n⏳ FloodWait no envio (%v). Aguardando...\n", d)
-			time.Sleep(d + 1*time.Second)
-			return c.UploadAndSendDocument(ctx, filePath, caption)
+		return nil, err
+	}
+
+	inputMedia := &tg.InputMediaUploadedDocument{
+		File:       fileUpload,
+		MimeType:   documentMimeType(fileName),
+		Attributes: []tg.DocumentAttributeClass{&tg.DocumentAttributeFilename{FileName: fileName}},
+		ForceFile:  true,
+	}
+
+	sent, err := c.sendMedia(ctx, replyTo, inputMedia, caption)
+	if err != nil {
+		return nil, fmt.Errorf("erro envio doc: %w", err)
+	}
+	return sent, nil
+}
+
+// documentMimeType is the type of the file, the subtitles are not in every mime database
+func documentMimeType(fileName string) string {
+	switch ext := strings.ToLower(filepath.Ext(fileName)); ext {
+	case ".srt":
+		return "application/x-subrip"
+	case ".vtt":
+		return "text/vtt"
+	default:
+		if t := mime.TypeByExtension(ext); t != "" {
+			return t
 		}
-		return fmt.Errorf("erro envio doc: %w", err)
+		return "application/octet-stream"
 	}
+}
 
-	fmt.Println("✅ Documento enviado com sucesso")
-	return nil
+func (c *Client) sendMedia(ctx context.Context, replyTo int, media tg.InputMediaClass, caption string) (*SentMessage, error) {
+	if c.TargetPeer == nil {
+		return nil, fmt.Errorf("TargetPeer nulo")
+	}
+
+	for {
+		builder := c.sender.To(c.TargetPeer).CloneBuilder()
+		if replyTo != 0 {
+			builder = builder.Reply(replyTo)
+		}
+		updates, err := builder.Media(ctx, message.Media(media, html.String(nil, caption)))
+		if err == nil {
+			return newSentMessage(updates), nil
+		}
+
+		// Treating floodAwait
+		d, ok := tgerr.AsFloodWait(err)
+		if !ok {
+			return nil, err
+		}
+
+		fmt.Printf("\n⏳ FloodWait no envio (%v). Aguardando...\n", d)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(d + 1*time.Second):
+		}
+	}
 }
 
 func (c *Client) SendMessage(ctx context.Context, text string) (int, error) {
@@ -213,6 +373,14 @@ func (c *Client) SendMessage(ctx context.Context, text string) (int, error) {
 	return extractMsgID(updates), nil
 }
 
+func (c *Client) EditMessage(ctx context.Context, messageID int, text string) error {
+	if c.TargetPeer == nil {
+		return fmt.Errorf("TargetPeer nulo")
+	}
+	_, err := c.sender.To(c.TargetPeer).Edit(messageID).StyledText(ctx, html.String(nil, text))
+	return err
+}
+
 func (c *Client) PinMessage(ctx context.Context, messageID int) error {
 	if c.TargetPeer == nil {
 		return fmt.Errorf("TargetPeer nulo")
@@ -224,22 +392,107 @@ func (c *Client) PinMessage(ctx context.Context, messageID int) error {
 }
 
 func extractMsgID(updates tg.UpdatesClass) int {
-	switch u := updates.(type) {
-	case *tg.UpdateShortSentMessage:
+	if u, ok := updates.(*tg.UpdateShortSentMessage); ok {
 		return u.ID
-	case *tg.Updates:
-		for _, update := range u.Updates {
-			if msg, ok := update.(*tg.UpdateNewChannelMessage); ok {
-				if m, ok := msg.Message.(*tg.Message); ok {
-					return m.ID
-				}
+	}
+	if m := extractMessage(updates); m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+// extractMessage finds the message that we just sent inside the updates
+func extractMessage(updates tg.UpdatesClass) *tg.Message {
+	u, ok := updates.(*tg.Updates)
+	if !ok {
+		return nil
+	}
+
+	for _, update := range u.Updates {
+		if msg, ok := update.(*tg.UpdateNewChannelMessage); ok {
+			if m, ok := msg.Message.(*tg.Message); ok {
+				return m
 			}
-			if msg, ok := update.(*tg.UpdateNewMessage); ok {
-				if m, ok := msg.Message.(*tg.Message); ok {
-					return m.ID
-				}
+		}
+		if msg, ok := update.(*tg.UpdateNewMessage); ok {
+			if m, ok := msg.Message.(*tg.Message); ok {
+				return m
 			}
 		}
 	}
-	return 0
+	return nil
+}
+
+func newSentMessage(updates tg.UpdatesClass) *SentMessage {
+	if m := extractMessage(updates); m != nil {
+		return sentFromMessage(m)
+	}
+	return &SentMessage{
+		MessageID: extractMsgID(updates),
+		Date:      time.Now(),
+	}
+}
+
+func sentFromMessage(m *tg.Message) *SentMessage {
+	sent := &SentMessage{
+		MessageID: m.ID,
+		Date:      time.Unix(int64(m.Date), 0),
+	}
+
+	if media, ok := m.Media.(*tg.MessageMediaDocument); ok {
+		if doc, ok := media.Document.(*tg.Document); ok {
+			sent.DocumentID = doc.ID
+			sent.AccessHash = doc.AccessHash
+			sent.Size = doc.Size
+		}
+	}
+	return sent
+}
+
+// FetchMessages gets the messages of the target chat by ID. Deleted messages
+// are left out of the result.
+func (c *Client) FetchMessages(ctx context.Context, ids []int) (map[int]*SentMessage, error) {
+	if c.TargetPeer == nil {
+		return nil, fmt.Errorf("TargetPeer nulo")
+	}
+
+	found := make(map[int]*SentMessage)
+	const batchSize = 100
+
+	for start := 0; start < len(ids); start += batchSize {
+		end := min(start+batchSize, len(ids))
+
+		var input []tg.InputMessageClass
+		for _, id := range ids[start:end] {
+			input = append(input, &tg.InputMessageID{ID: id})
+		}
+
+		var res tg.MessagesMessagesClass
+		var err error
+		if p, ok := c.TargetPeer.(*tg.InputPeerChannel); ok {
+			res, err = c.client.API().ChannelsGetMessages(ctx, &tg.ChannelsGetMessagesRequest{
+				Channel: &tg.InputChannel{ChannelID: p.ChannelID, AccessHash: p.AccessHash},
+				ID:      input,
+			})
+		} else {
+			res, err = c.client.API().MessagesGetMessages(ctx, input)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar mensagens: %w", err)
+		}
+
+		modified, ok := res.AsModified()
+		if !ok {
+			continue
+		}
+		for _, msg := range modified.GetMessages() {
+			m, ok := msg.(*tg.Message)
+			if !ok {
+				continue
+			}
+			found[m.ID] = sentFromMessage(m)
+		}
+	}
+
+	return found, nil
 }
@@ -282,6 +282,7 @@ func (c *Client) CheckChatAccess(ctx context.Context) error {
 			if len(r.Chats) > 0 {
 				if ch, ok := r.Chats[0].(*tg.Channel); ok {
 					c.TargetPeer = &tg.InputPeerChannel{ChannelID: ch.ID, AccessHash: ch.AccessHash}
+					c.username = ch.Username
 					fmt.Printf("✅ Canal: %s\n", ch.Title)
 					return nil
 				}
@@ -290,6 +291,7 @@ func (c *Client) CheckChatAccess(ctx context.Context) error {
 			if len(r.Chats) > 0 {
 				if ch, ok := r.Chats[0].(*tg.Channel); ok {
 					c.TargetPeer = &tg.InputPeerChannel{ChannelID: ch.ID, AccessHash: ch.AccessHash}
+					c.username = ch.Username
 					fmt.Printf("✅ Canal: %s\n", ch.Title)
 					return nil
 				}
@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -22,6 +23,30 @@ func (c *Client) GetTargetChatID() int64 {
 	return c.chatID
 }
 
+// MessageLink returns the t.me link of a message in the target chat.
+// Basic groups don't have message links, so it returns an empty string.
+func (c *Client) MessageLink(messageID int) string {
+	if messageID == 0 {
+		return ""
+	}
+
+	p, ok := c.TargetPeer.(*tg.InputPeerChannel)
+	if !ok {
+		return ""
+	}
+
+	if c.username != "" {
+		return fmt.Sprintf("https://t.me/%s/%d", c.username, messageID)
+	}
+	return fmt.Sprintf("https://t.me/c/%d/%d", p.ChannelID, messageID)
+}
+
+// ErrAuth wraps the failures of the login (phone, code or 2FA password)
+var ErrAuth = errors.New("falha na autenticação")
+
+// Threads used by each file upload
+const uploadThreads = 6
+
 type Client struct {
 	client   *telegram.Client
 	sender   *message.Sender
@@ -35,6 +60,7 @@ type Client struct {
 
 	// Group that will be uploaded
 	chatID     int64
+	username   string // Public username of the channel, if any
 	TargetPeer tg.InputPeerClass
 
 	// Group that we gonna share
@@ -55,13 +81,15 @@ func NewClient(cfg *config.Config) *Client {
 	}
 }
 
+// SessionPath is the file of the persistent session of the phone
+func SessionPath(phone string) string {
+	return filepath.Join("session", fmt.Sprintf("session_%s.json", phone))
+}
+
 func (c *Client) Start(ctx context.Context, runLogic func(ctx context.Context) error) error {
 	// Sessão persistente
-	sessionDir := "session"
-	_ = os.MkdirAll(sessionDir, 0700)
-
-	sessionFileName := fmt.Sprintf("session_%s.json", c.phone)
-	sessionPath := filepath.Join(sessionDir, sessionFileName)
+	sessionPath := SessionPath(c.phone)
+	_ = os.MkdirAll(filepath.Dir(sessionPath), 0700)
 
 	fmt.Printf("🔐 Arquivo de Sessão: %s\n", sessionPath)
 
@@ -80,19 +108,34 @@ func (c *Client) Start(ctx context.Context, runLogic func(ctx context.Context) e
 
 	return c.client.Run(ctx, func(ctx context.Context) error {
 		if err := c.authenticate(ctx); err != nil {
-			return err
+			return fmt.Errorf("%w: %w", ErrAuth, err)
 		}
 
 		raw := c.client.API()
 		c.sender = message.NewSender(raw)
 
-		c.uploader = uploader.NewUploader(raw).WithThreads(6)
+		c.uploader = c.newUploader()
 
 		fmt.Println("🤖 Userbot conectado e pronto!")
 		return runLogic(ctx)
 	})
 }
 
+// IsPremium reads the flags of the logged in user
+func (c *Client) IsPremium(ctx context.Context) (bool, error) {
+	self, err := c.client.Self(ctx)
+	if err != nil {
+		return false, fmt.Errorf("erro ao ler a conta: %w", err)
+	}
+	return self.Premium, nil
+}
+
+// newUploader returns a fresh uploader. Each concurrent upload needs its own
+// instance because WithProgress mutates the uploader in place.
+func (c *Client) newUploader() *uploader.Uploader {
+	return uploader.NewUploader(c.client.API()).WithThreads(uploadThreads)
+}
+
 // Authenticate manages the login (Phone -> Code -> 2FA Password)
 func (c *Client) authenticate(ctx context.Context) error {
 	flow := auth.NewFlow(
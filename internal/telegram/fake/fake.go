@@ -0,0 +1,535 @@
+// Package fake is an in-memory Telegram for the tests. A Server keeps the chats
+// and their messages, the Clients created from it implement telegram.Service
+// like the real client does against the API.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gotd/td/tg"
+
+	"github.com/FolcloreX/GopherGram/internal/config"
+	"github.com/FolcloreX/GopherGram/internal/processor"
+	"github.com/FolcloreX/GopherGram/internal/telegram"
+)
+
+// SavedMessages is the chat of the announcements when no POST_GROUP_ID is set
+const SavedMessages int64 = 0
+
+// Kind of a message
+const (
+	KindText     = "text"
+	KindVideo    = "video"
+	KindAudio    = "audio"
+	KindDocument = "document"
+	KindPhoto    = "photo"
+)
+
+type Message struct {
+	ID         int
+	Kind       string
+	Text       string // Text or caption
+	FileName   string
+	MimeType   string
+	Streaming  bool // SupportsStreaming of the video
+	Duration   int  // Of the video or audio attribute
+	Title      string
+	Performer  string // Title and performer of the audio player
+	Thumb      string // Name of the uploaded thumbnail or cover, empty if none
+	Size       int64
+	DocumentID int64
+	Edited     bool
+	ReplyTo    int
+	Date       time.Time
+}
+
+type Chat struct {
+	ID         int64
+	Title      string
+	About      string
+	Photo      string // Path of the photo set with UpdateChannelInfo
+	InviteLink string
+	Pinned     int
+	Messages   []*Message
+
+	nextID int
+}
+
+// Server is the Telegram shared by the clients (runs) of a test
+type Server struct {
+	mu     sync.Mutex
+	chats  map[int64]*Chat
+	nextID int64
+	nextFD int64
+	files  map[int64]upload
+
+	// Returned by Start, as a failed login
+	AuthErr error
+
+	// The account of the clients is Premium, see IsPremium
+	Premium bool
+
+	// Called before each upload with the file name, an error fails the upload
+	FailUpload func(fileName string) error
+}
+
+type upload struct {
+	name string
+	size int64
+}
+
+func NewServer() *Server {
+	return &Server{
+		chats:  map[int64]*Chat{SavedMessages: {ID: SavedMessages, Title: "Saved Messages"}},
+		nextID: 1000,
+		files:  make(map[int64]upload),
+	}
+}
+
+// AddChat creates a chat that already exists before the run (ORIGIN_CHAT_ID, POST_GROUP_ID)
+func (s *Server) AddChat(id int64, title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chats[id] = &Chat{ID: id, Title: title}
+}
+
+// Chat returns a copy of the chat, nil if it doesn't exist
+func (s *Server) Chat(id int64) *Chat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.chats[id]
+	if !ok {
+		return nil
+	}
+	cp := *c
+	cp.Messages = make([]*Message, len(c.Messages))
+	for i, m := range c.Messages {
+		msg := *m
+		cp.Messages[i] = &msg
+	}
+	return &cp
+}
+
+// Chats lists the id of every chat but the saved messages, in creation order
+func (s *Server) Chats() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id := range s.chats {
+		if id != SavedMessages {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// DeleteMessage removes a message from the chat, like an admin would
+func (s *Server) DeleteMessage(chatID int64, messageID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.chats[chatID]
+	for i, m := range c.Messages {
+		if m.ID == messageID {
+			c.Messages = append(c.Messages[:i], c.Messages[i+1:]...)
+			return
+		}
+	}
+}
+
+// Client creates a client of the server with the config, like telegram.NewClient
+func (s *Server) Client(cfg *config.Config) *Client {
+	postID := SavedMessages
+	if cfg.PostGroupID != 0 {
+		postID = cfg.PostGroupID
+	}
+	return &Client{server: s, chatID: cfg.ChatID, postID: postID}
+}
+
+func (s *Server) post(chatID int64, msg *Message) (*Message, error) {
+	c, ok := s.chats[chatID]
+	if !ok {
+		return nil, fmt.Errorf("CHAT_ID_INVALID: %d", chatID)
+	}
+	c.nextID++
+	msg.ID = c.nextID
+	msg.Date = time.Now()
+	c.Messages = append(c.Messages, msg)
+	return msg, nil
+}
+
+func (s *Server) message(chatID int64, id int) *Message {
+	c, ok := s.chats[chatID]
+	if !ok {
+		return nil
+	}
+	for _, m := range c.Messages {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+// Client is a session in the server, it implements telegram.Service
+type Client struct {
+	server   *Server
+	chatID   int64
+	postID   int64
+	resolved bool
+	started  bool
+}
+
+var _ telegram.Service = (*Client)(nil)
+
+func (c *Client) Start(ctx context.Context, runLogic func(ctx context.Context) error) error {
+	if c.server.AuthErr != nil {
+		return fmt.Errorf("%w: %w", telegram.ErrAuth, c.server.AuthErr)
+	}
+	c.started = true
+	return runLogic(ctx)
+}
+
+func (c *Client) IsPremium(ctx context.Context) (bool, error) {
+	if !c.started {
+		return false, fmt.Errorf("cliente não conectado (rode Start)")
+	}
+	return c.server.Premium, nil
+}
+
+func (c *Client) CheckChatAccess(ctx context.Context) error {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	if _, ok := c.server.chats[c.chatID]; !ok || c.chatID == SavedMessages {
+		return fmt.Errorf("não foi possível encontrar o chat com ID %d", c.chatID)
+	}
+	c.resolved = true
+	return nil
+}
+
+func (c *Client) CreateOriginChannel(ctx context.Context, title string) error {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	c.server.nextID++
+	c.chatID = c.server.nextID
+	c.server.chats[c.chatID] = &Chat{ID: c.chatID, Title: title}
+	c.resolved = true
+	return nil
+}
+
+func (c *Client) GetTargetChatID() int64 {
+	return c.chatID
+}
+
+func (c *Client) MessageLink(messageID int) string {
+	if messageID == 0 || !c.resolved {
+		return ""
+	}
+	return fmt.Sprintf("https://t.me/c/%d/%d", c.chatID, messageID)
+}
+
+func (c *Client) UploadVideo(ctx context.Context, filePath string, meta *processor.VideoMeta) (tg.InputMediaClass, error) {
+	file, err := c.upload(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	mimeType := meta.MimeType
+	if mimeType == "" {
+		mimeType = processor.VideoMimeType(filePath)
+	}
+	doc := &tg.InputMediaUploadedDocument{
+		File:     file,
+		MimeType: mimeType,
+		Attributes: []tg.DocumentAttributeClass{&tg.DocumentAttributeVideo{
+			Duration:          float64(meta.Duration),
+			W:                 meta.Width,
+			H:                 meta.Height,
+			SupportsStreaming: meta.Streamable,
+		}},
+	}
+	doc.Thumb = c.uploadThumb(ctx, meta.ThumbPath)
+	return doc, nil
+}
+
+func (c *Client) UploadAudio(ctx context.Context, filePath string, meta *processor.AudioMeta) (tg.InputMediaClass, error) {
+	file, err := c.upload(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	doc := &tg.InputMediaUploadedDocument{
+		File:     file,
+		MimeType: telegram.AudioMimeType(filePath),
+		Attributes: []tg.DocumentAttributeClass{&tg.DocumentAttributeAudio{
+			Duration:  meta.Duration,
+			Title:     meta.Title,
+			Performer: meta.Performer,
+		}},
+	}
+	doc.Thumb = c.uploadThumb(ctx, meta.CoverPath)
+	return doc, nil
+}
+
+// uploadThumb uploads the thumbnail or cover, best-effort like the real client
+func (c *Client) uploadThumb(ctx context.Context, path string) tg.InputFileClass {
+	if path == "" {
+		return nil
+	}
+	thumb, err := c.upload(ctx, path)
+	if err != nil {
+		return nil
+	}
+	return thumb
+}
+
+func (c *Client) SendMedia(ctx context.Context, media tg.InputMediaClass, caption string) (*telegram.SentMessage, error) {
+	return c.sendMedia(ctx, 0, media, caption)
+}
+
+func (c *Client) ReplyWithDocument(ctx context.Context, replyTo int, filePath string, caption string) (*telegram.SentMessage, error) {
+	file, err := c.upload(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return c.sendMedia(ctx, replyTo, &tg.InputMediaUploadedDocument{File: file, MimeType: "application/x-subrip", ForceFile: true}, caption)
+}
+
+func (c *Client) sendMedia(ctx context.Context, replyTo int, media tg.InputMediaClass, caption string) (*telegram.SentMessage, error) {
+	if err := c.ready(ctx); err != nil {
+		return nil, err
+	}
+
+	doc, ok := media.(*tg.InputMediaUploadedDocument)
+	if !ok {
+		return nil, fmt.Errorf("mídia não suportada: %T", media)
+	}
+	file, ok := doc.File.(*tg.InputFile)
+	if !ok {
+		return nil, fmt.Errorf("arquivo não suportado: %T", doc.File)
+	}
+
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	up, ok := c.server.files[file.ID]
+	if !ok {
+		return nil, fmt.Errorf("FILE_PART_MISSING: %d", file.ID)
+	}
+
+	msg := &Message{Text: caption, ReplyTo: replyTo, MimeType: doc.MimeType, FileName: up.name, Size: up.size}
+	for _, attr := range doc.Attributes {
+		switch attr := attr.(type) {
+		case *tg.DocumentAttributeVideo:
+			msg.Streaming = attr.SupportsStreaming
+			msg.Duration = int(attr.Duration)
+		case *tg.DocumentAttributeAudio:
+			msg.Duration = attr.Duration
+			msg.Title = attr.Title
+			msg.Performer = attr.Performer
+		}
+	}
+	if thumb, ok := doc.Thumb.(*tg.InputFile); ok {
+		msg.Thumb = thumb.Name
+	}
+
+	msg.Kind = KindDocument
+	switch {
+	case strings.HasPrefix(doc.MimeType, "video/"):
+		msg.Kind = KindVideo
+	case strings.HasPrefix(doc.MimeType, "audio/"):
+		msg.Kind = KindAudio
+	}
+
+	c.server.nextFD++
+	msg.DocumentID = c.server.nextFD
+	msg, err := c.server.post(c.chatID, msg)
+	if err != nil {
+		return nil, err
+	}
+	return sentMessage(msg), nil
+}
+
+func (c *Client) UploadAndSendDocument(ctx context.Context, filePath string, caption string) (*telegram.SentMessage, error) {
+	file, err := c.upload(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return c.SendMedia(ctx, &tg.InputMediaUploadedDocument{File: file, MimeType: "application/zip", ForceFile: true}, caption)
+}
+
+func (c *Client) SendMessage(ctx context.Context, text string) (int, error) {
+	if err := c.ready(ctx); err != nil {
+		return 0, err
+	}
+
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	msg, err := c.server.post(c.chatID, &Message{Kind: KindText, Text: text})
+	if err != nil {
+		return 0, err
+	}
+	return msg.ID, nil
+}
+
+func (c *Client) EditMessage(ctx context.Context, messageID int, text string) error {
+	if err := c.ready(ctx); err != nil {
+		return err
+	}
+
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	msg := c.server.message(c.chatID, messageID)
+	if msg == nil {
+		return fmt.Errorf("MESSAGE_ID_INVALID: %d", messageID)
+	}
+	msg.Text = text
+	msg.Edited = true
+	return nil
+}
+
+func (c *Client) PinMessage(ctx context.Context, messageID int) error {
+	if err := c.ready(ctx); err != nil {
+		return err
+	}
+
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	if c.server.message(c.chatID, messageID) == nil {
+		return fmt.Errorf("MESSAGE_ID_INVALID: %d", messageID)
+	}
+	c.server.chats[c.chatID].Pinned = messageID
+	return nil
+}
+
+func (c *Client) FetchMessages(ctx context.Context, ids []int) (map[int]*telegram.SentMessage, error) {
+	if err := c.ready(ctx); err != nil {
+		return nil, err
+	}
+
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	found := make(map[int]*telegram.SentMessage)
+	for _, id := range ids {
+		if msg := c.server.message(c.chatID, id); msg != nil {
+			found[id] = sentMessage(msg)
+		}
+	}
+	return found, nil
+}
+
+func (c *Client) GenerateInviteLink(ctx context.Context) (string, error) {
+	if err := c.ready(ctx); err != nil {
+		return "", err
+	}
+
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	chat := c.server.chats[c.chatID]
+	chat.InviteLink = fmt.Sprintf("https://t.me/+fake%d", c.chatID)
+	return chat.InviteLink, nil
+}
+
+func (c *Client) UpdateChannelInfo(ctx context.Context, coverPath string, bio string) error {
+	if err := c.ready(ctx); err != nil {
+		return err
+	}
+
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	chat := c.server.chats[c.chatID]
+	chat.About = bio
+	if coverPath != "" {
+		chat.Photo = coverPath
+	}
+	return nil
+}
+
+func (c *Client) ResolvePostTarget(ctx context.Context) error {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	if _, ok := c.server.chats[c.postID]; !ok {
+		return fmt.Errorf("não foi possível encontrar o chat com ID %d", c.postID)
+	}
+	return nil
+}
+
+func (c *Client) SendAnnouncement(ctx context.Context, coverPath string, caption string) error {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	msg := &Message{Kind: KindText, Text: caption}
+	if coverPath != "" {
+		msg.Kind = KindPhoto
+		msg.FileName = filepath.Base(coverPath)
+	}
+	_, err := c.server.post(c.postID, msg)
+	return err
+}
+
+// upload registers the file in the server, like the uploader sending its parts
+func (c *Client) upload(ctx context.Context, filePath string) (*tg.InputFile, error) {
+	if err := c.ready(ctx); err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(filePath)
+	if c.server.FailUpload != nil {
+		if err := c.server.FailUpload(name); err != nil {
+			return nil, err
+		}
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+
+	c.server.nextFD++
+	id := c.server.nextFD
+	c.server.files[id] = upload{name: name, size: info.Size()}
+	return &tg.InputFile{ID: id, Name: name}, nil
+}
+
+// ready fails like the real client when it's not connected or the chat wasn't resolved
+func (c *Client) ready(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !c.started {
+		return fmt.Errorf("cliente não conectado (rode Start)")
+	}
+	if !c.resolved {
+		return fmt.Errorf("TargetPeer nulo (rode CheckChatAccess)")
+	}
+	return nil
+}
+
+func sentMessage(m *Message) *telegram.SentMessage {
+	return &telegram.SentMessage{
+		MessageID:  m.ID,
+		DocumentID: m.DocumentID,
+		Size:       m.Size,
+		Date:       m.Date,
+	}
+}
@@ -0,0 +1,44 @@
+package telegram
+
+import (
+	"context"
+
+	"github.com/gotd/td/tg"
+
+	"github.com/FolcloreX/GopherGram/internal/processor"
+)
+
+// Service is every Telegram operation used by the uploader. Client talks to
+// the real API, the fake package has an in-memory one for the tests.
+type Service interface {
+	// Start connects and logs in, then runs the logic while connected
+	Start(ctx context.Context, runLogic func(ctx context.Context) error) error
+
+	// IsPremium reports if the logged in account is Premium (uploads up to 4GB)
+	IsPremium(ctx context.Context) (bool, error)
+
+	// Target chat
+	CheckChatAccess(ctx context.Context) error
+	CreateOriginChannel(ctx context.Context, title string) error
+	GetTargetChatID() int64
+	MessageLink(messageID int) string
+
+	// Uploads and messages in the target chat
+	UploadVideo(ctx context.Context, filePath string, meta *processor.VideoMeta) (tg.InputMediaClass, error)
+	UploadAudio(ctx context.Context, filePath string, meta *processor.AudioMeta) (tg.InputMediaClass, error)
+	SendMedia(ctx context.Context, media tg.InputMediaClass, caption string) (*SentMessage, error)
+	UploadAndSendDocument(ctx context.Context, filePath string, caption string) (*SentMessage, error)
+	ReplyWithDocument(ctx context.Context, replyTo int, filePath string, caption string) (*SentMessage, error)
+	SendMessage(ctx context.Context, text string) (int, error)
+	EditMessage(ctx context.Context, messageID int, text string) error
+	PinMessage(ctx context.Context, messageID int) error
+	FetchMessages(ctx context.Context, ids []int) (map[int]*SentMessage, error)
+
+	// Channel profile and announcement
+	GenerateInviteLink(ctx context.Context) (string, error)
+	UpdateChannelInfo(ctx context.Context, coverPath string, bio string) error
+	ResolvePostTarget(ctx context.Context) error
+	SendAnnouncement(ctx context.Context, coverPath string, caption string) error
+}
+
+var _ Service = (*Client)(nil)
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package workdir
+
+import "golang.org/x/sys/unix"
+
+// freeSpace is how many bytes an unprivileged user can still write in the disk of the folder
+func freeSpace(dir string) (int64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package workdir
+
+import "golang.org/x/sys/windows"
+
+// freeSpace is how many bytes the user can still write in the disk of the folder
+func freeSpace(dir string) (int64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &free, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return int64(free), nil
+}
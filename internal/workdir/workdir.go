@@ -0,0 +1,128 @@
+// Package workdir manages the scratch folder of a run, where the split parts and
+// the converted copies of the videos are written. Each run has its own folder,
+// so a crashed run never mixes its files with the next one.
+package workdir
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/FolcloreX/GopherGram/internal/lock"
+	"github.com/FolcloreX/GopherGram/internal/processor"
+)
+
+const (
+	runPrefix = "gophergram-run-"
+	lockFile  = "run.lock"
+)
+
+// A folder without lock younger than this may be a run that is starting
+const startGrace = time.Minute
+
+// Space left free in the disk after each operation, for the state, the logs and the system
+const reserve int64 = 200 * 1024 * 1024
+
+// ErrNoSpace is returned by EnsureFree when the disk of the work folder is too full
+var ErrNoSpace = errors.New("espaço insuficiente na pasta de trabalho")
+
+// Dir is the folder of the current run, locked while it's in use
+type Dir struct {
+	Path string
+	lock *lock.Lock
+}
+
+// Create makes the folder of the run inside base (the temp folder of the system if empty)
+func Create(base string) (*Dir, error) {
+	if base == "" {
+		base = os.TempDir()
+	}
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return nil, fmt.Errorf("erro ao criar pasta de trabalho: %w", err)
+	}
+
+	path, err := os.MkdirTemp(base, runPrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar pasta de trabalho: %w", err)
+	}
+
+	l, err := lock.Acquire(filepath.Join(path, lockFile), "pasta de trabalho", false)
+	if err != nil {
+		os.RemoveAll(path)
+		return nil, err
+	}
+	return &Dir{Path: path, lock: l}, nil
+}
+
+// Sub creates (if needed) a subfolder of the run. Ex: the folder of a video
+func (d *Dir) Sub(parts ...string) (string, error) {
+	path := filepath.Join(append([]string{d.Path}, parts...)...)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return "", fmt.Errorf("erro ao criar pasta de trabalho: %w", err)
+	}
+	return path, nil
+}
+
+// EnsureFree checks that the disk of the run folder has room for need bytes
+// more (plus a reserve), before a step that writes a big file.
+func (d *Dir) EnsureFree(need int64, what string) error {
+	return EnsureFree(d.Path, need, what)
+}
+
+// EnsureFree checks that the disk of dir has room for need bytes more (plus a
+// reserve). If the free space can't be read it doesn't block.
+func EnsureFree(dir string, need int64, what string) error {
+	free, err := freeSpace(dir)
+	if err != nil {
+		return nil
+	}
+	if free < need+reserve {
+		return fmt.Errorf("%w: %s precisa de %s, há %s livres em %s (mude com WORK_DIR)",
+			ErrNoSpace, what, processor.FormatSize(need+reserve), processor.FormatSize(free), dir)
+	}
+	return nil
+}
+
+// Remove deletes the folder with everything inside
+func (d *Dir) Remove() error {
+	d.lock.Release()
+	return os.RemoveAll(d.Path)
+}
+
+// FindStale lists the folders of runs that crashed or were killed: the
+// process that locked them doesn't exist anymore.
+func FindStale(base string) ([]string, error) {
+	if base == "" {
+		base = os.TempDir()
+	}
+
+	entries, err := os.ReadDir(base)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), runPrefix) {
+			continue
+		}
+
+		path := filepath.Join(base, entry.Name())
+		if lock.InUse(filepath.Join(path, lockFile)) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(path, lockFile)); os.IsNotExist(err) {
+			if info, err := entry.Info(); err != nil || time.Since(info.ModTime()) < startGrace {
+				continue
+			}
+		}
+		stale = append(stale, path)
+	}
+	return stale, nil
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/FolcloreX/GopherGram/internal/processor"
+)
+
+// Announcement is what the course card and the channel description show
+type Announcement struct {
+	Title         string
+	Description   string
+	Author        string
+	Year          int
+	CoverPath     string
+	Logo          string
+	TotalSize     int64
+	TotalDuration int // Seconds
+}
+
+// Announce generates the invite link, updates the channel profile and posts the
+// course card. The post target must be resolved (ResolvePostTarget).
+// Failures are only logged, returns the invite link (empty if it failed).
+func Announce(ctx context.Context, bot Telegram, a Announcement) string {
+	inviteLink, err := bot.GenerateInviteLink(ctx)
+	if err != nil {
+		log.Printf("⚠️ Erro link convite: %v", err)
+		inviteLink = ""
+	} else {
+		fmt.Printf("🔗 Link do Curso: %s\n", inviteLink)
+	}
+
+	// Update the channel description
+	channelBio := processor.FormatChannelBio(
+		a.TotalSize,
+		a.TotalDuration,
+		inviteLink,
+		a.Logo,
+	)
+
+	fmt.Println("\n🎨 Personalizando Canal...")
+	if err := bot.UpdateChannelInfo(ctx, a.CoverPath, channelBio); err != nil {
+		log.Printf("⚠️ Erro ao atualizar perfil: %v", err)
+	}
+
+	// Create the invite message
+	cardCaption := processor.FormatCourseCard(
+		processor.CourseInfo{Title: a.Title, Description: a.Description, Author: a.Author, Year: a.Year},
+		a.TotalSize,
+		a.TotalDuration,
+		a.Logo,
+		inviteLink,
+	)
+
+	if err := bot.SendAnnouncement(ctx, a.CoverPath, cardCaption); err != nil {
+		log.Printf("❌ Erro ao postar anúncio: %v", err)
+	}
+
+	return inviteLink
+}
@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/FolcloreX/GopherGram/internal/domain"
+	"github.com/FolcloreX/GopherGram/internal/index"
+	"github.com/FolcloreX/GopherGram/internal/processor"
+	"github.com/FolcloreX/GopherGram/internal/state"
+	"github.com/FolcloreX/GopherGram/internal/workdir"
+)
+
+// splitZip splits the zip in parts of up to limit. A variable so the tests don't
+// need a zip bigger than the limit.
+var splitZip = processor.SplitFileBinary
+
+// uploadAssets zips the support files and sends the zip, splitted if needed
+func (r *runner) uploadAssets(ctx context.Context, course *Course, menu *index.Menu) error {
+	zipName := r.opts.ZipPath
+	zipper := processor.Zipper{RootDir: course.RootPath}
+
+	// The zip is at most the size of the files, and the parts as big as the zip
+	assetsSize := processor.CalculateAssetsSize(course.Assets)
+	if err := workdir.EnsureFree(filepath.Dir(zipName), assetsSize, "o zip do material de apoio"); err != nil {
+		return err
+	}
+	if err := zipper.ZipFiles(course.Assets, zipName); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(zipName); err == nil && info.Size() > r.maxSize {
+		if err := workdir.EnsureFree(filepath.Dir(zipName), info.Size(), "as partes do zip"); err != nil {
+			os.Remove(zipName)
+			return err
+		}
+	}
+	parts, err := splitZip(zipName, r.maxSize)
+	if err != nil {
+		return err
+	}
+
+	for i, part := range parts {
+		// Generates the tag (Ex: Doc001, Doc002...)
+		docTag := fmt.Sprintf("%s%03d", domain.HashTagDoc, i+1)
+		entry := index.Entry{Tag: docTag, Title: fmt.Sprintf("🗂 Arquivo %d/%d", i+1, len(parts))}
+		event := Event{Phase: PhaseAssets, Path: part, Part: i + 1, Parts: len(parts)}
+
+		key, err := state.Fingerprint(part)
+		if err != nil {
+			return err
+		}
+		event.Key = key
+
+		// Check if the part is not already uploaded
+		if r.prog.IsDone(key, legacyZipPath(i, len(parts))) {
+			fmt.Printf("⏩ Pulando (já enviado): %s\n", filepath.Base(part))
+			if rec, ok := r.prog.GetPart(key); ok {
+				entry.Link = r.bot.MessageLink(rec.MessageID)
+				event.Message = sentFromRecord(rec)
+			}
+			menu.AddDoc(entry)
+			os.Remove(part) // Remove because we already sent
+
+			event.Kind = EventPartSkipped
+			r.result.Skipped++
+			r.emit(event)
+			continue
+		}
+
+		caption := fmt.Sprintf("#%s 🗂 <b>Material de Apoio</b>\nArquivo %d/%d",
+			docTag, i+1, len(parts))
+
+		sent, err := r.bot.UploadAndSendDocument(ctx, part, caption)
+		if err != nil {
+			return err
+		}
+
+		// If uploaded sucessfully we mark as done in the state
+		if err := r.prog.MarkAsUploaded(key, newPartRecord(part, sent, caption)); err != nil {
+			log.Printf("⚠️ Erro ao salvar estado: %v", err)
+			r.emit(Event{Kind: EventError, Phase: PhaseAssets, Path: part, Key: key, Err: err})
+		}
+		entry.Link = r.bot.MessageLink(sent.MessageID)
+		menu.AddDoc(entry)
+		os.Remove(part)
+
+		event.Kind = EventPartUploaded
+		event.Message = sent
+		r.result.Uploaded++
+		r.emit(event)
+	}
+
+	os.Remove(zipName)
+	return nil
+}
+
+// sentFromRecord is the message of a part uploaded in a previous run
+func sentFromRecord(rec PartRecord) *SentMessage {
+	if rec.MessageID == 0 {
+		return nil
+	}
+	return &SentMessage{
+		MessageID:  rec.MessageID,
+		DocumentID: rec.DocumentID,
+		AccessHash: rec.AccessHash,
+		Size:       rec.Size,
+		Date:       rec.UploadedAt,
+	}
+}
+
+// legacyZipPath is the key of the zip part in the states of the versions keyed by
+// path, which wrote the zip to the working directory (Arquivos.zip, Arquivos.part1.zip...)
+func legacyZipPath(i, total int) string {
+	if total == 1 {
+		return "Arquivos.zip"
+	}
+	return fmt.Sprintf("Arquivos.part%d.zip", i+1)
+}
@@ -0,0 +1,40 @@
+package pipeline
+
+// Phase of the upload
+type Phase string
+
+const (
+	PhaseAssets   Phase = "assets"   // Zip of the support files
+	PhaseVideos   Phase = "videos"   // Split, upload and post of the videos
+	PhaseIndex    Phase = "index"    // Menu with the links, pinned
+	PhaseAnnounce Phase = "announce" // Invite, channel profile and announcement
+)
+
+type EventKind string
+
+const (
+	EventPhaseStarted EventKind = "phase_started"
+	EventPartUploaded EventKind = "part_uploaded"
+	EventPartSkipped  EventKind = "part_skipped" // Already in the state
+	EventError        EventKind = "error"        // Fatal (returned by Run) or not (the run goes on)
+)
+
+// Event is reported to Options.OnEvent while the course is uploaded
+type Event struct {
+	Kind  EventKind
+	Phase Phase
+
+	// Parts: the file uploaded, its key in the state and its position.
+	// Video is nil for the support files.
+	Path  string
+	Key   string
+	Part  int // 1-based
+	Parts int
+	Video *Video
+
+	// Message that holds the part. For skipped parts it comes from the state
+	// and may be nil for files uploaded by older versions.
+	Message *SentMessage
+
+	Err error
+}
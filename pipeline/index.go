@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/FolcloreX/GopherGram/internal/domain"
+	"github.com/FolcloreX/GopherGram/internal/index"
+	"github.com/FolcloreX/GopherGram/internal/state"
+)
+
+// SendIndex posts the menu, one message per page, cross-links the pages and pins the first one.
+// Failing to link or pin the pages is only logged.
+func SendIndex(ctx context.Context, bot Telegram, menu *index.Menu) error {
+	pages := menu.Pages(index.MaxMessageLength)
+	fmt.Printf("📨 Enviando Menu de Links (%d página(s))... ", len(pages))
+
+	msgIDs := make([]int, len(pages))
+	links := make([]string, len(pages))
+
+	for i, page := range pages {
+		msgID, err := bot.SendMessage(ctx, page+index.Navigation(i, links))
+		if err != nil {
+			log.Printf("Erro ao enviar Index (página %d/%d): %v", i+1, len(pages), err)
+			return fmt.Errorf("erro ao enviar menu (página %d/%d): %w", i+1, len(pages), err)
+		}
+		msgIDs[i] = msgID
+	}
+
+	// Now that we know the messages we can link the pages between them
+	if len(pages) > 1 {
+		for i, msgID := range msgIDs {
+			links[i] = bot.MessageLink(msgID)
+		}
+		for i, page := range pages {
+			if links[i] == "" {
+				continue
+			}
+			if err := bot.EditMessage(ctx, msgIDs[i], page+index.Navigation(i, links)); err != nil {
+				log.Printf("Aviso: Falha ao ligar a página %d do Index: %v", i+1, err)
+			}
+		}
+	}
+
+	if err := bot.PinMessage(ctx, msgIDs[0]); err != nil {
+		log.Printf("Aviso: Falha ao pinar mensagem: %v", err)
+	} else {
+		fmt.Println("📌 Menu fixado com sucesso!")
+	}
+	return nil
+}
+
+// RebuildMenu creates the menu from the records of the state, following the
+// order of the course. Returns how many videos have no record.
+func RebuildMenu(bot Telegram, style string, course *Course, records map[string]PartRecord) (*index.Menu, int) {
+	menu := index.New(index.Style(style))
+
+	// The zip parts are found by their caption, the zip is recreated on every run
+	var docs []index.Entry
+	for _, rec := range records {
+		tag, rest, ok := strings.Cut(rec.Caption, " ")
+		tag, isDoc := strings.CutPrefix(tag, "#"+domain.HashTagDoc)
+		if !ok || !isDoc {
+			continue
+		}
+
+		// Caption: #Doc001 🗂 <b>Material de Apoio</b>\nArquivo 1/2
+		title := "🗂 Arquivo"
+		if _, part, found := strings.Cut(rest, "\nArquivo "); found {
+			title += " " + part
+		}
+		docs = append(docs, index.Entry{
+			Tag:   domain.HashTagDoc + tag,
+			Title: title,
+			Link:  bot.MessageLink(rec.MessageID),
+		})
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].Tag < docs[j].Tag })
+	for _, doc := range docs {
+		menu.AddDoc(doc)
+	}
+
+	missing := 0
+	sections := map[*Module]*index.Section{}
+	for _, mod := range course.AllModules() {
+		var section *index.Section
+
+		for _, video := range mod.Videos {
+			fingerprint, err := state.Fingerprint(video.FilePath)
+			if err != nil {
+				log.Printf("⚠️ Erro ao ler %s: %v", video.FileName, err)
+				missing++
+				continue
+			}
+
+			rec, ok := state.FirstPart(records, fingerprint)
+			if !ok {
+				missing++
+				continue
+			}
+
+			if section == nil {
+				section = moduleSection(menu, mod, sections)
+			}
+			section.Add(videoEntry(video, bot.MessageLink(rec.MessageID)))
+		}
+	}
+
+	return menu, missing
+}
+
+// moduleSection adds the section of the module to the menu, after the sections
+// of its parents that are not there yet
+func moduleSection(menu *index.Menu, mod *Module, sections map[*Module]*index.Section) *index.Section {
+	if section, ok := sections[mod]; ok {
+		return section
+	}
+	if mod.Parent != nil {
+		moduleSection(menu, mod.Parent, sections)
+	}
+
+	section := menu.AddSection(mod.Name)
+	section.Level = mod.Depth() - 1
+	sections[mod] = section
+	return section
+}
+
+// videoEntry is the line of the video in the menu
+func videoEntry(video *Video, link string) index.Entry {
+	title := fmt.Sprintf("%d - %s", video.Sequence, video.CleanTitle())
+	if video.IsAudio() {
+		title = "🎧 " + title
+	}
+	return index.Entry{
+		Tag:   video.ID,
+		Title: title,
+		Link:  link,
+	}
+}
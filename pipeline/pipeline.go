@@ -0,0 +1,383 @@
+// Package pipeline uploads a course to Telegram: support files, videos, the
+// index and the announcement. It's what the gophergram command runs, and can
+// be embedded by other programs through Run.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/FolcloreX/GopherGram/internal/config"
+	"github.com/FolcloreX/GopherGram/internal/domain"
+	"github.com/FolcloreX/GopherGram/internal/index"
+	"github.com/FolcloreX/GopherGram/internal/processor"
+	"github.com/FolcloreX/GopherGram/internal/scanner"
+	"github.com/FolcloreX/GopherGram/internal/state"
+	"github.com/FolcloreX/GopherGram/internal/telegram"
+	"github.com/FolcloreX/GopherGram/internal/workdir"
+)
+
+// Types of the internal packages used in the API, so other modules can use them
+type (
+	Course        = domain.Course
+	Module        = domain.Module
+	Video         = domain.Video
+	Config        = config.Config
+	Telegram      = telegram.Service
+	SentMessage   = telegram.SentMessage
+	ProgressStore = state.ProgressStore
+	PartRecord    = state.PartRecord
+)
+
+// Scan reads the course folder: modules, videos and support files
+func Scan(root string) (*Course, error) {
+	return scanner.New(root).Scan()
+}
+
+// NewTelegram creates the real Telegram client of the account in the config
+func NewTelegram(cfg *Config) Telegram {
+	return telegram.NewClient(cfg)
+}
+
+// OpenStore opens the state of the course (backend "json" or "sqlite")
+func OpenStore(backend, dbPath, courseName string) (ProgressStore, error) {
+	return state.OpenStore(backend, dbPath, courseName)
+}
+
+const (
+	DefaultWorkers = 2
+	DefaultZipPath = "Arquivos.zip"
+)
+
+// How the videos Telegram can't stream are sent, see domain.ConvertRemux
+const (
+	ConvertNone      = domain.ConvertNone
+	ConvertRemux     = domain.ConvertRemux
+	ConvertTranscode = domain.ConvertTranscode
+)
+
+// How big the uploaded files can be, see domain.SizeCompatible
+const (
+	SizeCompatible = domain.SizeCompatible
+	SizePremium    = domain.SizePremium
+)
+
+// How the subtitles of the videos are sent
+const (
+	SubtitlesReply = "reply" // Document replying to the video
+	SubtitlesMux   = "mux"   // Tracks inside the video, can be turned off in the player
+	SubtitlesBurn  = "burn"  // Drawn in the frames, the video is re-encoded
+)
+
+type Options struct {
+	// Telegram client, Run connects it. The chat configured in the client is
+	// used, or a new channel named Title is created.
+	Telegram Telegram
+	// What was already uploaded, the files found there are skipped
+	Store ProgressStore
+
+	// Name of the course, defaults to the title of the manifest or the name of its folder
+	Title string
+	// Photo of the channel and of the announcement, defaults to the cover of the manifest (optional)
+	CoverPath string
+	// Signature of the messages
+	Logo string
+	// "links" (default) or "hashtags"
+	IndexStyle string
+
+	PrepareWorkers int // Videos being splitted/thumbnailed at the same time
+	UploadWorkers  int // Files being uploaded at the same time
+
+	// How the subtitles next to the videos are sent: SubtitlesReply (default), SubtitlesMux or SubtitlesBurn
+	SubtitleMode string
+	// Language of the subtitle burned when a video has more than one. Ex: pt-BR
+	SubtitleLang string
+
+	// What to do with the videos Telegram can't stream: ConvertNone, ConvertRemux or ConvertTranscode.
+	// Defaults to the convert of the course.yaml, then ConvertRemux.
+	Convert string
+
+	// Size of the files before they are splitted: SizeCompatible (default, 2GB) or
+	// SizePremium (4GB when the account is Premium, 2GB otherwise)
+	SizeLimit string
+
+	// Where the zip of the support files is created, DefaultZipPath inside the folder of the run if empty
+	ZipPath string
+	// Where the folder of the run (zip, split parts, thumbnails, converted copies) is created,
+	// the temp folder of the system if empty. Nothing is written in the course folder.
+	WorkDir string
+
+	// Don't post the announcement (phase 4)
+	SkipAnnounce bool
+
+	// Called on every event, from one goroutine at a time
+	OnEvent func(Event)
+}
+
+// Result is the summary of a successful run
+type Result struct {
+	ChatID        int64
+	InviteLink    string
+	Uploaded      int
+	Skipped       int
+	TotalSize     int64
+	TotalDuration int // Seconds
+}
+
+// PhaseError is a failure in the middle of the upload. What was sent before it
+// is in the state, running again continues from there.
+type PhaseError struct {
+	Phase Phase
+	Err   error
+}
+
+func (e *PhaseError) Error() string { return e.Err.Error() }
+func (e *PhaseError) Unwrap() error { return e.Err }
+
+// Run connects to Telegram and uploads the course in 4 phases:
+// support files, videos, index and announcement.
+func Run(ctx context.Context, course *Course, opts Options) (*Result, error) {
+	if opts.Telegram == nil || opts.Store == nil {
+		return nil, errors.New("pipeline: Telegram e Store são obrigatórios")
+	}
+	if opts.Title == "" {
+		opts.Title = course.Title
+	}
+	if opts.Title == "" {
+		opts.Title = filepath.Base(course.RootPath)
+	}
+	if opts.CoverPath == "" {
+		opts.CoverPath = course.CoverPath
+	}
+	if opts.PrepareWorkers < 1 {
+		opts.PrepareWorkers = DefaultWorkers
+	}
+	if opts.UploadWorkers < 1 {
+		opts.UploadWorkers = DefaultWorkers
+	}
+	if opts.IndexStyle == "" {
+		opts.IndexStyle = string(index.StyleLinks)
+	}
+	if opts.SubtitleMode == "" {
+		opts.SubtitleMode = SubtitlesReply
+	}
+	if opts.Convert == "" {
+		opts.Convert = course.Convert
+	}
+	if opts.Convert == "" {
+		opts.Convert = ConvertRemux
+	}
+	if opts.SizeLimit == "" {
+		opts.SizeLimit = SizeCompatible
+	}
+
+	cleanLeftovers(opts.WorkDir)
+
+	work, err := workdir.Create(opts.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+	defer work.Remove()
+
+	if opts.ZipPath == "" {
+		opts.ZipPath = filepath.Join(work.Path, DefaultZipPath)
+	}
+
+	r := &runner{bot: opts.Telegram, prog: opts.Store, opts: opts, work: work, maxSize: domain.MaxFileSize, result: &Result{}}
+
+	if err := r.prog.StartRun(); err != nil {
+		log.Printf("⚠️ Erro ao registrar execução: %v", err)
+	}
+
+	err = r.bot.Start(ctx, func(ctx context.Context) error {
+		if err := r.resolveChat(ctx); err != nil {
+			return err
+		}
+		r.resolveSizeLimit(ctx)
+		return r.upload(ctx, course)
+	})
+	if err != nil {
+		r.emit(Event{Kind: EventError, Err: err})
+	}
+
+	if err := r.prog.FinishRun(err); err != nil {
+		log.Printf("⚠️ Erro ao registrar fim da execução: %v", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.result, nil
+}
+
+// runner keeps what the phases share
+type runner struct {
+	bot     Telegram
+	prog    ProgressStore
+	opts    Options
+	work    *workdir.Dir // Folder of this run, removed at the end
+	maxSize int64        // Files bigger than this are splitted, see resolveSizeLimit
+	result  *Result
+}
+
+// cleanLeftovers removes the folders of the runs whose process is gone. The
+// course folder is never touched, the parts and thumbnails older versions left
+// next to the videos are ignored by the scanner and removed only by clean.
+func cleanLeftovers(workDir string) {
+	stale, err := workdir.FindStale(workDir)
+	if err != nil {
+		log.Printf("⚠️ Erro ao procurar pastas de trabalho abandonadas: %v", err)
+	}
+	for _, dir := range stale {
+		if err := os.RemoveAll(dir); err == nil {
+			fmt.Printf("🧹 Pasta de trabalho abandonada removida: %s\n", dir)
+		}
+	}
+}
+
+func (r *runner) emit(e Event) {
+	if r.opts.OnEvent != nil {
+		r.opts.OnEvent(e)
+	}
+}
+
+// resolveChat checks the configured chat, or creates a new channel for the course
+func (r *runner) resolveChat(ctx context.Context) error {
+	// Check if a group was passed otherwise create a new one
+	if r.bot.GetTargetChatID() != 0 {
+		if err := r.bot.CheckChatAccess(ctx); err != nil {
+			return fmt.Errorf("erro ao acessar chat origem: %w", err)
+		}
+	} else {
+		if err := r.bot.CreateOriginChannel(ctx, r.opts.Title); err != nil {
+			return fmt.Errorf("erro ao criar canal: %w", err)
+		}
+	}
+
+	newID := r.bot.GetTargetChatID()
+	r.result.ChatID = newID
+
+	// Save in the state file the CHAT_ID
+	if err := r.prog.SetChatID(newID); err != nil {
+		log.Printf("⚠️ CRÍTICO: Não foi possível salvar o ID no estado: %v", err)
+	} else {
+		fmt.Printf("💾 ID %d salvo no arquivo de estado com sucesso!\n", newID)
+	}
+	return nil
+}
+
+// resolveSizeLimit picks the size of the parts from the SizeLimit policy. The
+// Premium limit needs a Premium account, otherwise the uploads would fail.
+func (r *runner) resolveSizeLimit(ctx context.Context) {
+	if r.opts.SizeLimit != SizePremium {
+		return
+	}
+
+	premium, err := r.bot.IsPremium(ctx)
+	if err != nil {
+		log.Printf("⚠️ Não foi possível saber se a conta é Premium (usando 2GB): %v", err)
+		return
+	}
+	if !premium {
+		fmt.Println("⚠️ SIZE_LIMIT=premium, mas a conta não é Premium: arquivos divididos em 2GB")
+		return
+	}
+
+	r.maxSize = domain.FileSizeLimit(r.opts.SizeLimit, premium)
+	fmt.Printf("💎 Conta Premium: arquivos de até %s\n", processor.FormatSize(r.maxSize))
+}
+
+func (r *runner) upload(ctx context.Context, course *Course) error {
+	// Resolve the PostGroup, if no one is passed we send to the saved messages
+	if !r.opts.SkipAnnounce {
+		if err := r.bot.ResolvePostTarget(ctx); err != nil {
+			return fmt.Errorf("erro ao resolver grupo de divulgação: %w", err)
+		}
+	}
+
+	// Metadada to perform information in the invite and the description
+	r.result.TotalSize = processor.CalculateAssetsSize(course.Assets)
+	r.result.TotalSize += processor.CalculateVideosSize(course.AllModules())
+
+	totalVideos := len(course.Videos())
+
+	fmt.Printf("📊 Resumo: %d Módulos | %d Vídeos | %d Assets (Arquivos Extra)\n",
+		len(course.AllModules()), totalVideos, len(course.Assets))
+
+	if totalVideos == 0 && len(course.Assets) == 0 {
+		fmt.Println("⚠️  Nenhum arquivo encontrado! Verifique se a pasta está correta.")
+		return nil
+	}
+
+	// Create the menu index
+	menu := index.New(index.Style(r.opts.IndexStyle))
+
+	if len(course.Assets) > 0 {
+		r.startPhase(PhaseAssets, "📂 [FASE 1] Processando Material de Apoio")
+
+		if err := r.uploadAssets(ctx, course, menu); err != nil {
+			return &PhaseError{Phase: PhaseAssets, Err: err}
+		}
+	}
+
+	if totalVideos > 0 {
+		r.startPhase(PhaseVideos, "🎬 [FASE 2] Processando Vídeos")
+
+		fmt.Printf("⚙️  Paralelismo: %d preparando | %d enviando\n", r.opts.PrepareWorkers, r.opts.UploadWorkers)
+
+		v := &videoPipeline{runner: r}
+		duration, err := v.Run(ctx, course.AllModules(), menu)
+		r.result.TotalDuration = duration
+		if err != nil {
+			return &PhaseError{Phase: PhaseVideos, Err: err}
+		}
+
+		r.startPhase(PhaseIndex, "📑 [FASE 3] Finalização")
+
+		if err := SendIndex(ctx, r.bot, menu); err != nil {
+			r.emit(Event{Kind: EventError, Phase: PhaseIndex, Err: err})
+		}
+	}
+
+	if !r.opts.SkipAnnounce {
+		r.startPhase(PhaseAnnounce, "📢 [FASE 4] Divulgação")
+
+		r.result.InviteLink = Announce(ctx, r.bot, Announcement{
+			Title:         r.opts.Title,
+			Description:   course.Description,
+			Author:        course.Author,
+			Year:          course.Year,
+			CoverPath:     r.opts.CoverPath,
+			Logo:          r.opts.Logo,
+			TotalSize:     r.result.TotalSize,
+			TotalDuration: r.result.TotalDuration,
+		})
+	}
+
+	fmt.Println("\n✅✅✅ PROCESSO CONCLUÍDO! ✅✅✅")
+	return nil
+}
+
+func (r *runner) startPhase(phase Phase, title string) {
+	fmt.Println("\n------------------------------------------------")
+	fmt.Println(title)
+	fmt.Println("------------------------------------------------")
+
+	r.emit(Event{Kind: EventPhaseStarted, Phase: phase})
+}
+
+// newPartRecord converts the sent message into the record saved in the state
+func newPartRecord(path string, sent *SentMessage, caption string) PartRecord {
+	return PartRecord{
+		Path:       path,
+		MessageID:  sent.MessageID,
+		DocumentID: sent.DocumentID,
+		AccessHash: sent.AccessHash,
+		Size:       sent.Size,
+		UploadedAt: sent.Date,
+		Caption:    caption,
+	}
+}
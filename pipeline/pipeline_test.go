@@ -0,0 +1,531 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/FolcloreX/GopherGram/internal/config"
+	"github.com/FolcloreX/GopherGram/internal/domain"
+	"github.com/FolcloreX/GopherGram/internal/lock"
+	"github.com/FolcloreX/GopherGram/internal/state"
+	"github.com/FolcloreX/GopherGram/internal/telegram/fake"
+)
+
+func writeCourse(t *testing.T, files ...string) *Course {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), "Curso")
+	for _, name := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Repeat(name, 50)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	course, err := Scan(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return course
+}
+
+func newOptions(t *testing.T, srv *fake.Server, store ProgressStore, events *[]Event) Options {
+	return Options{
+		Telegram: srv.Client(&config.Config{}),
+		Store:    store,
+		ZipPath:  filepath.Join(t.TempDir(), "Arquivos.zip"),
+		WorkDir:  t.TempDir(),
+		OnEvent:  func(e Event) { *events = append(*events, e) },
+	}
+}
+
+// testRun is the fixture of the tests: a course, an empty state, the fake
+// Telegram and the options of an upload without the announcement.
+type testRun struct {
+	course *Course
+	store  *state.ProgressManager
+	srv    *fake.Server
+	opts   Options
+	events []Event
+}
+
+func newTestRun(t *testing.T, files ...string) *testRun {
+	t.Helper()
+	store, err := state.Load(filepath.Join(t.TempDir(), "progress.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := &testRun{course: writeCourse(t, files...), store: store, srv: fake.NewServer()}
+	tr.opts = newOptions(t, tr.srv, store, &tr.events)
+	tr.opts.SkipAnnounce = true
+	return tr
+}
+
+// run uploads the course, failing the test on error
+func (tr *testRun) run(t *testing.T) *Result {
+	t.Helper()
+	result, err := Run(context.Background(), tr.course, tr.opts)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return result
+}
+
+// resume prepares a new run in the channel created by the previous one
+func (tr *testRun) resume() {
+	tr.events = nil
+	tr.opts.Telegram = tr.srv.Client(&config.Config{ChatID: tr.store.GetChatID()})
+}
+
+func kinds(events []Event, kind EventKind) []Event {
+	var list []Event
+	for _, e := range events {
+		if e.Kind == kind {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// pinned is the text of the pinned message (the menu)
+func pinned(chat *fake.Chat) string {
+	for _, msg := range chat.Messages {
+		if msg.ID == chat.Pinned {
+			return msg.Text
+		}
+	}
+	return ""
+}
+
+func TestRunReportsEvents(t *testing.T) {
+	tr := newTestRun(t, "01 Intro/01 a.mp4", "01 Intro/02 b.mp4", "01 Intro/slides.pdf")
+	tr.opts.SkipAnnounce = false
+	result := tr.run(t)
+
+	var phases []string
+	for _, e := range kinds(tr.events, EventPhaseStarted) {
+		phases = append(phases, string(e.Phase))
+	}
+	if got := strings.Join(phases, ","); got != "assets,videos,index,announce" {
+		t.Errorf("fases = %s", got)
+	}
+
+	uploaded := kinds(tr.events, EventPartUploaded)
+	if len(uploaded) != 3 || result.Uploaded != 3 {
+		t.Fatalf("enviados = %d eventos / %d no resultado, esperado 3", len(uploaded), result.Uploaded)
+	}
+	if uploaded[0].Video != nil || uploaded[1].Video.ID != "F001" || uploaded[2].Video.ID != "F002" {
+		t.Errorf("ordem dos envios errada: %+v", uploaded)
+	}
+	for _, e := range uploaded {
+		if e.Message == nil || e.Message.MessageID == 0 || e.Key == "" {
+			t.Errorf("evento sem mensagem ou chave: %+v", e)
+		}
+	}
+
+	if result.ChatID == 0 || result.InviteLink == "" {
+		t.Errorf("resultado incompleto: %+v", result)
+	}
+	if tr.store.GetChatID() != result.ChatID {
+		t.Errorf("chat salvo = %d, esperado %d", tr.store.GetChatID(), result.ChatID)
+	}
+}
+
+func TestRunSkipsUploadedParts(t *testing.T) {
+	tr := newTestRun(t, "01 Intro/01 a.mp4", "01 Intro/02 b.mp4")
+	tr.opts.SkipAnnounce = false
+	tr.srv.FailUpload = func(name string) error {
+		if name == "02 b.mp4" {
+			return errors.New("conexão perdida")
+		}
+		return nil
+	}
+
+	_, err := Run(context.Background(), tr.course, tr.opts)
+
+	var phaseErr *PhaseError
+	if !errors.As(err, &phaseErr) || phaseErr.Phase != PhaseVideos {
+		t.Fatalf("erro = %v, esperado PhaseError da fase videos", err)
+	}
+	if len(kinds(tr.events, EventError)) == 0 {
+		t.Error("nenhum evento de erro")
+	}
+
+	// Second run in the same chat: the first video is skipped
+	tr.srv.FailUpload = nil
+	tr.resume()
+	tr.opts.SkipAnnounce = true
+	result := tr.run(t)
+
+	skipped := kinds(tr.events, EventPartSkipped)
+	if len(skipped) != 1 || skipped[0].Video.ID != "F001" || skipped[0].Message == nil {
+		t.Errorf("pulados = %+v, esperado F001 com a mensagem do estado", skipped)
+	}
+	if result.Uploaded != 1 || result.Skipped != 1 {
+		t.Errorf("resultado = %+v", result)
+	}
+	if saved := tr.srv.Chat(fake.SavedMessages); len(saved.Messages) != 0 {
+		t.Error("anúncio postado com SkipAnnounce")
+	}
+}
+
+func TestRunNestedModules(t *testing.T) {
+	tr := newTestRun(t, "1. Intro/1. Boas-vindas/01 a.mp4", "1. Intro/2. Setup/01 b.mp4", "2. Fim/01 c.mp4")
+	result := tr.run(t)
+
+	chat := tr.srv.Chat(result.ChatID)
+	if caption := chat.Messages[0].Text; !strings.HasSuffix(caption, "\n1. Intro › 1. Boas-vindas") {
+		t.Errorf("legenda = %q", caption)
+	}
+
+	menu := pinned(chat)
+	want := "📁 <b>1. Intro</b>\n\n└ 📂 <b>1. Boas-vindas</b>\n"
+	if !strings.Contains(menu, want) || !strings.Contains(menu, "└ 📂 <b>2. Setup</b>") {
+		t.Errorf("menu sem a hierarquia:\n%s", menu)
+	}
+}
+
+func TestRunRepliesWithSubtitles(t *testing.T) {
+	tr := newTestRun(t, "01 Intro/01 a.mp4", "01 Intro/01 a.srt", "01 Intro/01 a.en.srt")
+	result := tr.run(t)
+
+	chat := tr.srv.Chat(result.ChatID)
+	video := chat.Messages[0]
+	var replies []string
+	for _, msg := range chat.Messages {
+		if msg.ReplyTo == video.ID {
+			replies = append(replies, msg.Text)
+		}
+	}
+	if got := strings.Join(replies, "|"); got != "#F001 💬 Legenda (en)|#F001 💬 Legenda" {
+		t.Errorf("respostas ao vídeo = %q", got)
+	}
+	if result.Uploaded != 3 {
+		t.Errorf("enviados = %d, esperado o vídeo e as 2 legendas", result.Uploaded)
+	}
+
+	// The subtitles are in the state too
+	tr.resume()
+	result = tr.run(t)
+	if result.Uploaded != 0 || result.Skipped != 3 {
+		t.Errorf("resultado do resume = %+v", result)
+	}
+}
+
+func TestRunSendsSubtitlesOfTheSameLanguage(t *testing.T) {
+	tr := newTestRun(t, "01 Intro/01 a.mp4", "01 Intro/01 a.srt", "01 Intro/01 a.vtt", "01 Intro/01 a.en.srt", "01 Intro/01 a.en.vtt")
+	result := tr.run(t)
+
+	var names []string
+	for _, msg := range tr.srv.Chat(result.ChatID).Messages {
+		if msg.ReplyTo != 0 {
+			names = append(names, msg.FileName)
+		}
+	}
+	if len(names) != 4 {
+		t.Errorf("legendas enviadas = %v, esperado as 4", names)
+	}
+
+	tr.resume()
+	result = tr.run(t)
+	if result.Uploaded != 0 || result.Skipped != 5 {
+		t.Errorf("resultado do resume = %+v", result)
+	}
+}
+
+func TestRunSendsTheSameSubtitleToEveryVideo(t *testing.T) {
+	tr := newTestRun(t, "01 Intro/01 a.mp4", "01 Intro/01 a.srt", "01 Intro/02 b.mp4", "01 Intro/02 b.srt")
+	// Same content, as the empty .srt of a course without subtitles
+	for _, name := range []string{"01 a.srt", "02 b.srt"} {
+		if err := os.WriteFile(filepath.Join(tr.course.RootPath, "01 Intro", name), []byte("1\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result := tr.run(t)
+
+	replies := 0
+	for _, msg := range tr.srv.Chat(result.ChatID).Messages {
+		if msg.ReplyTo != 0 {
+			replies++
+		}
+	}
+	if replies != 2 || result.Uploaded != 4 {
+		t.Errorf("legendas enviadas = %d (enviados %d), esperado uma por vídeo", replies, result.Uploaded)
+	}
+}
+
+func TestRunSkipsSubtitlesWithoutTheVideoMessage(t *testing.T) {
+	tr := newTestRun(t, "01 Intro/01 a.mp4", "01 Intro/01 a.srt")
+
+	// Sent by an old version that didn't keep the message
+	video := tr.course.Videos()[0]
+	fingerprint, err := state.Fingerprint(video.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.store.MarkAsUploaded(fingerprint, state.PartRecord{Path: video.FilePath}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := tr.run(t)
+
+	for _, msg := range tr.srv.Chat(result.ChatID).Messages {
+		if msg.Kind == fake.KindDocument {
+			t.Errorf("legenda enviada sem a mensagem do vídeo: %q (resposta a %d)", msg.Text, msg.ReplyTo)
+		}
+	}
+	if errs := kinds(tr.events, EventError); len(errs) != 1 || errs[0].Path != video.Subtitles[0].Path {
+		t.Errorf("erros = %+v, esperado o da legenda", errs)
+	}
+}
+
+func TestRunSendsAudioLessons(t *testing.T) {
+	tr := newTestRun(t, "01 Podcast/01 abertura.mp3", "01 Podcast/02 aula.mp4")
+	result := tr.run(t)
+
+	chat := tr.srv.Chat(result.ChatID)
+	if audio, video := chat.Messages[0], chat.Messages[1]; audio.Kind != fake.KindAudio || video.Kind != fake.KindVideo {
+		t.Fatalf("tipos = %s, %s", audio.Kind, video.Kind)
+	}
+	if !strings.HasPrefix(chat.Messages[0].Text, "#F001 1 - 01 abertura") {
+		t.Errorf("legenda do áudio = %q", chat.Messages[0].Text)
+	}
+
+	if menu := pinned(chat); !strings.Contains(menu, "🎧 1 - 01 abertura") {
+		t.Errorf("menu sem o áudio:\n%s", menu)
+	}
+}
+
+func TestRunSendsContainerMimeType(t *testing.T) {
+	tr := newTestRun(t, "01 Intro/01 a.mp4", "01 Intro/02 b.mkv")
+	tr.opts.Convert = ConvertNone
+	result := tr.run(t)
+
+	chat := tr.srv.Chat(result.ChatID)
+	mp4, mkv := chat.Messages[0], chat.Messages[1]
+	if mp4.MimeType != "video/mp4" || !mp4.Streaming {
+		t.Errorf("mp4 = %s streaming=%v", mp4.MimeType, mp4.Streaming)
+	}
+	if mkv.MimeType != "video/x-matroska" || mkv.Streaming || mkv.Kind != fake.KindVideo {
+		t.Errorf("mkv = %s streaming=%v, esperado video/x-matroska sem streaming", mkv.MimeType, mkv.Streaming)
+	}
+}
+
+func TestRunCleansLeftovers(t *testing.T) {
+	tr := newTestRun(t, "01 Intro/01 a.mp4", "01 Intro/01 a_thumb.jpg", "01 Intro/01 a-part-002.mp4")
+
+	// Folder of a run whose process is gone, and one of a run still going
+	stale := filepath.Join(tr.opts.WorkDir, "gophergram-run-123")
+	running := filepath.Join(tr.opts.WorkDir, "gophergram-run-456")
+	for _, dir := range []string{stale, running} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	host, _ := os.Hostname()
+	info := fmt.Sprintf(`{"pid": 2147483000, "host": %q}`, host)
+	if err := os.WriteFile(filepath.Join(stale, "run.lock"), []byte(info), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := lock.Acquire(filepath.Join(running, "run.lock"), "teste", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Release()
+
+	tr.run(t)
+
+	entries, _ := os.ReadDir(tr.opts.WorkDir)
+	if len(entries) != 1 || entries[0].Name() != "gophergram-run-456" {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("pastas de trabalho = %v, esperado só a do processo vivo", names)
+	}
+
+	// The course folder is never written, the old leftovers stay for clean
+	for _, name := range []string{"01 a_thumb.jpg", "01 a-part-002.mp4"} {
+		if _, err := os.Stat(filepath.Join(tr.course.RootPath, "01 Intro", name)); err != nil {
+			t.Errorf("%s foi apagado da pasta do curso: %v", name, err)
+		}
+	}
+}
+
+func TestRunWritesOnlyInTheWorkDir(t *testing.T) {
+	tr := newTestRun(t, "01 Intro/01 a.mp4", "01 Intro/slides.pdf", "apostila.pdf")
+	tr.opts.SkipAnnounce = false
+	tr.opts.ZipPath = ""
+
+	listFiles := func() []string {
+		var files []string
+		filepath.WalkDir(tr.course.RootPath, func(path string, d fs.DirEntry, err error) error {
+			files = append(files, path)
+			return nil
+		})
+		return files
+	}
+	before := listFiles()
+
+	tr.run(t)
+
+	if after := listFiles(); strings.Join(after, "|") != strings.Join(before, "|") {
+		t.Errorf("pasta do curso alterada: %v, esperado %v", after, before)
+	}
+
+	uploaded := kinds(tr.events, EventPartUploaded)
+	if len(uploaded) == 0 || !strings.HasPrefix(uploaded[0].Path, tr.opts.WorkDir) {
+		t.Errorf("zip fora da pasta de trabalho: %+v", uploaded)
+	}
+
+	// The folder of the run is removed at the end
+	if entries, _ := os.ReadDir(tr.opts.WorkDir); len(entries) != 0 {
+		t.Errorf("pasta de trabalho não foi limpa: %v", entries)
+	}
+}
+
+func TestSizeLimitNeedsPremiumAccount(t *testing.T) {
+	tests := []struct {
+		policy  string
+		premium bool
+		want    int64
+	}{
+		{SizeCompatible, true, domain.MaxFileSize},
+		{SizePremium, false, domain.MaxFileSize},
+		{SizePremium, true, domain.MaxPremiumFileSize},
+	}
+	for _, tt := range tests {
+		srv := fake.NewServer()
+		srv.Premium = tt.premium
+		bot := srv.Client(&config.Config{})
+
+		r := &runner{bot: bot, opts: Options{SizeLimit: tt.policy}, maxSize: domain.MaxFileSize}
+		err := bot.Start(context.Background(), func(ctx context.Context) error {
+			r.resolveSizeLimit(ctx)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.maxSize != tt.want {
+			t.Errorf("%s (premium %v): limite = %d, esperado %d", tt.policy, tt.premium, r.maxSize, tt.want)
+		}
+	}
+}
+
+func TestSplitKeepsThePartsOfAnotherPolicy(t *testing.T) {
+	// The keyframes decide the cuts: 3 parts with 2000MiB, where the size alone gives 2
+	previous := splitVideo
+	var limits []int64
+	splitVideo = func(outDir, source string, limit int64) ([]string, error) {
+		limits = append(limits, limit)
+		n := 1
+		if limit == domain.MaxFileSize {
+			n = 3
+		}
+		var parts []string
+		for i := 1; i <= n; i++ {
+			parts = append(parts, filepath.Join(outDir, fmt.Sprintf("aula-part-%03d.mp4", i)))
+		}
+		return parts, nil
+	}
+	t.Cleanup(func() { splitVideo = previous })
+
+	store, err := state.Load(filepath.Join(t.TempDir(), "progress.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := &videoJob{video: &Video{FileName: "aula.mp4"}}
+	p := &videoPipeline{runner: &runner{prog: store, maxSize: domain.MaxPremiumFileSize}}
+
+	parts, err := p.split(job, "fp", "aula.mp4", t.TempDir())
+	if err != nil || len(parts) != 1 || len(limits) != 1 {
+		t.Errorf("sem envio anterior: %d partes, limites %v (%v), esperado 1 parte premium", len(parts), limits, err)
+	}
+
+	// First part sent by a run with SIZE_LIMIT=compatible
+	if err := store.MarkAsUploaded(state.PartKey("fp", 0, 3), PartRecord{MessageID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	limits = nil
+	parts, err = p.split(job, "fp", "aula.mp4", t.TempDir())
+	if err != nil || len(parts) != 3 || limits[len(limits)-1] != domain.MaxFileSize {
+		t.Errorf("enviado pela metade em 3 partes: %d partes, limites %v (%v)", len(parts), limits, err)
+	}
+
+	// Parts of a split that can't be made again: back to the limit of the account
+	store.Reset()
+	if err := store.MarkAsUploaded(state.PartKey("fp", 0, 2), PartRecord{MessageID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	limits = nil
+	parts, err = p.split(job, "fp", "aula.mp4", t.TempDir())
+	if err != nil || len(parts) != 1 || limits[len(limits)-1] != domain.MaxPremiumFileSize {
+		t.Errorf("divisão desconhecida: %d partes, limites %v (%v)", len(parts), limits, err)
+	}
+}
+
+func TestRunMigratesTheStateKeyedByPath(t *testing.T) {
+	tr := newTestRun(t, "01 a.mp4", "02 b.mp4", "03 c.mp4", "slides.pdf")
+	root := tr.course.RootPath
+
+	// The versions keyed by path split the videos next to them and the zip in the working directory
+	previousVideo, previousZip := splitVideo, splitZip
+	t.Cleanup(func() { splitVideo, splitZip = previousVideo, previousZip })
+	splitVideo = func(outDir, source string, limit int64) ([]string, error) {
+		if filepath.Base(source) != "01 a.mp4" {
+			return []string{source}, nil
+		}
+		return writeParts(t, outDir, "01 a-part-%03d.mp4", 2), nil
+	}
+	splitZip = func(zip string, limit int64) ([]string, error) {
+		return writeParts(t, filepath.Dir(zip), "Arquivos.part%d.zip", 2), nil
+	}
+
+	legacy := fmt.Sprintf(`{"target_chat_id": 0, "processed": {%q: true, %q: true, %q: true, "Arquivos.part1.zip": true, "Arquivos.part2.zip": true}}`,
+		filepath.Join(root, "01 a-part-0.mp4"), filepath.Join(root, "01 a-part-1.mp4"), filepath.Join(root, "02 b.mp4"))
+	path := filepath.Join(t.TempDir(), "progress.json")
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := state.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.store, tr.opts.Store = store, store
+
+	tr.run(t)
+
+	if skipped := kinds(tr.events, EventPartSkipped); len(skipped) != 5 {
+		t.Errorf("%d partes puladas, esperado as 2 do vídeo dividido, o vídeo inteiro e as 2 do zip", len(skipped))
+	}
+	if uploaded := kinds(tr.events, EventPartUploaded); len(uploaded) != 1 || filepath.Base(uploaded[0].Path) != "03 c.mp4" {
+		t.Errorf("enviados = %v, esperado só o vídeo novo", uploaded)
+	}
+	if len(store.Data.Processed) != 0 {
+		t.Errorf("entradas antigas não migradas: %v", store.Data.Processed)
+	}
+}
+
+// writeParts creates n parts named by the pattern (numbered from 1) in dir
+func writeParts(t *testing.T, dir, pattern string, n int) []string {
+	t.Helper()
+	var parts []string
+	for i := 1; i <= n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf(pattern, i))
+		if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, path)
+	}
+	return parts
+}
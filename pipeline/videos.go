@@ -0,0 +1,724 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gotd/td/tg"
+
+	"github.com/FolcloreX/GopherGram/internal/domain"
+	"github.com/FolcloreX/GopherGram/internal/index"
+	"github.com/FolcloreX/GopherGram/internal/processor"
+	"github.com/FolcloreX/GopherGram/internal/state"
+)
+
+// videoPipeline runs the videos through three stages: prepare (split + metadata),
+// upload and send. Preparing and uploading happen concurrently across videos,
+// but the messages are always sent in the Video.Sequence order.
+type videoPipeline struct {
+	*runner
+}
+
+// videoJob is one video flowing through the pipeline.
+// ready is closed once the video was splitted and the parts have metadata.
+type videoJob struct {
+	module *domain.Module
+	video  *domain.Video
+	parts  []*partJob
+	err    error
+	ready  chan struct{}
+
+	fingerprint string // Of the original video, the parts and the subtitles are keyed by it
+	replySubs   bool   // Send the subtitles as replies to the video
+	tempDir     string // Folder of the video in the run folder (parts, converted copies), removed when the video is done
+}
+
+// partJob is one file (the original or a splitted chunk) of a video.
+// uploaded is closed once the upload finished, successfully or not.
+type partJob struct {
+	path     string
+	key      string // Key in the state, see state.PartKey
+	meta     *processor.VideoMeta
+	audio    *processor.AudioMeta // Only for the audio lessons, sent with UploadAudio
+	skip     bool                 // Already sent in a previous run
+	media    tg.InputMediaClass
+	err      error
+	uploaded chan struct{}
+}
+
+// Run uploads every video of the modules (in the AllModules order), adding a
+// section per module and an entry per video to the menu. Returns the total duration in seconds of the videos.
+func (p *videoPipeline) Run(ctx context.Context, modules []*domain.Module, menu *index.Menu) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Limit how many videos can be ahead of the sender, so we don't fill the disk with chunks
+	lookahead := p.opts.PrepareWorkers + p.opts.UploadWorkers
+
+	orderCh := make(chan *videoJob, lookahead)
+	prepareCh := make(chan *videoJob)
+	uploadCh := make(chan *partJob)
+
+	var wg sync.WaitGroup
+
+	// Feeder: queue the videos in the order that they must be posted
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(orderCh)
+		defer close(prepareCh)
+
+		for _, mod := range modules {
+			for _, video := range mod.Videos {
+				job := &videoJob{module: mod, video: video, ready: make(chan struct{})}
+
+				select {
+				case orderCh <- job:
+				case <-ctx.Done():
+					return
+				}
+
+				select {
+				case prepareCh <- job:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	var prepareWg sync.WaitGroup
+	for i := 0; i < p.opts.PrepareWorkers; i++ {
+		prepareWg.Add(1)
+		go func() {
+			defer prepareWg.Done()
+			for job := range prepareCh {
+				if !p.prepare(ctx, job, uploadCh) {
+					return
+				}
+			}
+		}()
+	}
+
+	// The uploaders stop when every preparer is gone
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		prepareWg.Wait()
+		close(uploadCh)
+	}()
+
+	for i := 0; i < p.opts.UploadWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for part := range uploadCh {
+				if ctx.Err() != nil {
+					part.err = ctx.Err()
+				} else if part.audio != nil {
+					part.media, part.err = p.bot.UploadAudio(ctx, part.path, part.audio)
+				} else {
+					part.media, part.err = p.bot.UploadVideo(ctx, part.path, part.meta)
+				}
+				close(part.uploaded)
+			}
+		}()
+	}
+
+	totalDuration, err := p.send(ctx, orderCh, menu)
+
+	// Stop the workers that are still running and wait for them
+	cancel()
+	wg.Wait()
+
+	return totalDuration, err
+}
+
+// prepare splits the video and extracts the metadata of each part, then queues
+// the parts not yet uploaded. Returns false if the context was cancelled.
+func (p *videoPipeline) prepare(ctx context.Context, job *videoJob, uploadCh chan<- *partJob) bool {
+
+	// The parts are identified by the content of the original video, not by their paths
+	fingerprint, err := state.Fingerprint(job.video.FilePath)
+	if err != nil {
+		job.err = err
+		close(job.ready)
+		return true
+	}
+	job.fingerprint = fingerprint
+
+	if job.video.IsAudio() {
+		return p.prepareAudio(ctx, job, fingerprint, uploadCh)
+	}
+
+	job.replySubs = len(job.video.Subtitles) > 0 && p.opts.SubtitleMode == SubtitlesReply
+
+	// Everything was sent in a previous run, don't convert/split it again
+	if total, ok := state.UploadedParts(p.prog.Records(), fingerprint); ok {
+		p.prepareUploaded(job, fingerprint, total)
+		return true
+	}
+
+	source := job.video.FilePath
+	streamable := false
+	burn := len(job.video.Subtitles) > 0 && p.opts.SubtitleMode == SubtitlesBurn
+
+	// Burning re-encodes as a streamable MP4 already
+	if !burn {
+		source, streamable = p.convert(job, source)
+	}
+
+	if len(job.video.Subtitles) > 0 && p.opts.SubtitleMode != SubtitlesReply {
+		subtitled, err := p.addSubtitles(job, source)
+		if err != nil {
+			log.Printf("   ⚠️ Falha ao colocar as legendas em %s (enviando como resposta): %v", job.video.FileName, err)
+			job.replySubs = true
+			if burn {
+				source, streamable = p.convert(job, source)
+			}
+		} else {
+			// The converted copy isn't needed anymore
+			if source != job.video.FilePath {
+				os.Remove(source)
+			}
+			source = subtitled
+			streamable = streamable || burn
+		}
+	}
+
+	// Split the video if greater than the limit (2GB or 4GB)
+	partsDir, err := p.videoDir(job, "parts")
+	if err == nil {
+		err = p.ensureSplitSpace(job, source)
+	}
+	if err != nil {
+		job.err = err
+		close(job.ready)
+		return true
+	}
+	parts, err := p.split(job, fingerprint, source, partsDir)
+	if err != nil {
+		job.err = err
+		close(job.ready)
+		return true
+	}
+
+	// The parts are copies, the converted/subtitled video isn't needed anymore
+	if source != job.video.FilePath && len(parts) > 1 {
+		os.Remove(source)
+	}
+
+	thumbsDir, err := p.videoDir(job, "thumbs")
+	if err != nil {
+		job.err = err
+		close(job.ready)
+		return true
+	}
+
+	for i, partPath := range parts {
+		part := &partJob{
+			path:     partPath,
+			key:      state.PartKey(fingerprint, i, len(parts)),
+			uploaded: make(chan struct{}),
+		}
+
+		// Extracting metada
+		fmt.Printf("   📸 Gerando metadados para %s...\n", filepath.Base(partPath))
+		meta, err := processor.ExtractMetadata(partPath, thumbsDir)
+		if err != nil {
+			log.Printf("   ⚠️ Falha ao gerar thumbnail (enviando sem): %v", err)
+			meta = &processor.VideoMeta{}
+		}
+		meta.MimeType = processor.VideoMimeType(partPath)
+		meta.Streamable = streamable
+		part.meta = meta
+
+		// We keep the metadata of the sent parts to keep the total duration correct
+		if p.prog.IsDone(part.key, legacyPartPath(job.video.FilePath, i, len(parts))) {
+			part.skip = true
+			close(part.uploaded)
+		}
+
+		job.parts = append(job.parts, part)
+	}
+	close(job.ready)
+
+	for _, part := range job.parts {
+		if part.skip {
+			continue
+		}
+		select {
+		case uploadCh <- part:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// prepareAudio reads the tags and the cover of an audio lesson. Audios are never
+// splitted, they are small and a part couldn't be played alone.
+func (p *videoPipeline) prepareAudio(ctx context.Context, job *videoJob, fingerprint string, uploadCh chan<- *partJob) bool {
+	if job.video.Size > p.maxSize {
+		job.err = fmt.Errorf("áudio maior que %s: %s", processor.FormatSize(p.maxSize), job.video.FileName)
+		close(job.ready)
+		return true
+	}
+
+	// The subtitles (transcriptions) can only go as replies
+	job.replySubs = len(job.video.Subtitles) > 0
+
+	part := &partJob{
+		path:     job.video.FilePath,
+		key:      state.PartKey(fingerprint, 0, 1),
+		uploaded: make(chan struct{}),
+	}
+
+	coverDir, err := p.videoDir(job, "cover")
+	if err != nil {
+		job.err = err
+		close(job.ready)
+		return true
+	}
+
+	fmt.Printf("   🎧 Lendo metadados de %s...\n", job.video.FileName)
+	audio, err := processor.ExtractAudioMeta(part.path, coverDir)
+	if err != nil {
+		log.Printf("   ⚠️ Falha ao ler as tags do áudio (enviando sem): %v", err)
+		audio = &processor.AudioMeta{}
+	}
+	if audio.Title == "" {
+		audio.Title = job.video.CleanTitle()
+	}
+	part.audio = audio
+	part.meta = &processor.VideoMeta{Duration: audio.Duration, ThumbPath: audio.CoverPath}
+
+	if p.prog.IsDone(part.key, part.path) {
+		part.skip = true
+		close(part.uploaded)
+	}
+
+	job.parts = append(job.parts, part)
+	close(job.ready)
+
+	if part.skip {
+		return true
+	}
+	select {
+	case uploadCh <- part:
+	case <-ctx.Done():
+		return false
+	}
+	return true
+}
+
+// convert remuxes or transcodes the video into an MP4 that Telegram can stream,
+// following the Convert policy. Returns the file to send and if it's streamable.
+func (p *videoPipeline) convert(job *videoJob, source string) (string, bool) {
+	info, err := processor.ProbeContainer(source)
+	if err != nil {
+		// Without ffprobe trust the extension, as it was before
+		log.Printf("   ⚠️ Não foi possível ler o formato de %s: %v", job.video.FileName, err)
+		return source, processor.VideoMimeType(source) == "video/mp4"
+	}
+	if info.Streamable() {
+		return p.faststart(job, source), true
+	}
+
+	if p.opts.Convert == ConvertNone || (p.opts.Convert == ConvertRemux && !info.CanRemux()) {
+		fmt.Printf("   ⚠️ %s (%s) só toca depois de baixado, use VIDEO_CONVERT=transcode para converter\n",
+			job.video.FileName, info.Codecs())
+		return source, false
+	}
+
+	name := strings.TrimSuffix(job.video.FileName, filepath.Ext(job.video.FileName)) + ".mp4"
+	out, err := p.tempCopy(job, source, "convert", name)
+	if err != nil {
+		log.Printf("   ⚠️ Falha ao converter %s (enviando como está): %v", job.video.FileName, err)
+		return source, false
+	}
+
+	if info.CanRemux() {
+		fmt.Printf("   📦 Remuxando %s para MP4...\n", job.video.FileName)
+		err = processor.RemuxToMP4(source, out)
+	} else {
+		fmt.Printf("   🔄 Convertendo %s para H.264/AAC (recodificando, pode demorar)...\n", job.video.FileName)
+		err = processor.TranscodeToMP4(source, out)
+	}
+	if err != nil {
+		log.Printf("   ⚠️ Falha ao converter %s (enviando como está): %v", job.video.FileName, err)
+		os.Remove(out)
+		return source, false
+	}
+	return out, true
+}
+
+// faststart moves the index of the MP4 to the start when it's at the end, so
+// the video plays before being downloaded. Returns the file to send.
+func (p *videoPipeline) faststart(job *videoJob, source string) string {
+	needed, err := processor.NeedsFaststart(source)
+	if err != nil {
+		log.Printf("   ⚠️ Não foi possível ler o MP4 %s: %v", job.video.FileName, err)
+		return source
+	}
+	if !needed {
+		return source
+	}
+
+	out, err := p.tempCopy(job, source, "faststart", filepath.Base(source))
+	if err == nil {
+		fmt.Printf("   ⚡ Movendo o índice (moov) de %s para o início...\n", job.video.FileName)
+		err = processor.Faststart(source, out)
+	}
+	if err != nil {
+		log.Printf("   ⚠️ Falha no faststart de %s (enviando como está): %v", job.video.FileName, err)
+		os.Remove(out)
+		return source
+	}
+	return out
+}
+
+// addSubtitles creates a copy of the source with the subtitles muxed or burned.
+// The copy keeps the name of the video, it's shown in Telegram.
+func (p *videoPipeline) addSubtitles(job *videoJob, source string) (string, error) {
+	name := filepath.Base(source)
+	if p.opts.SubtitleMode == SubtitlesBurn {
+		// Re-encoded as H.264, always a .mp4
+		out, err := p.tempCopy(job, source, "subs", strings.TrimSuffix(name, filepath.Ext(name))+".mp4")
+		if err != nil {
+			return "", err
+		}
+
+		sub := processor.PickSubtitle(job.video.Subtitles, p.opts.SubtitleLang)
+		fmt.Printf("   🔥 Gravando legenda %s em %s (recodificando, pode demorar)...\n", filepath.Base(sub.Path), job.video.FileName)
+		return out, processor.BurnSubtitles(source, sub, out)
+	}
+
+	out, err := p.tempCopy(job, source, "subs", name)
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("   💬 Embutindo %d legenda(s) em %s...\n", len(job.video.Subtitles), job.video.FileName)
+	return out, processor.MuxSubtitles(source, job.video.Subtitles, out)
+}
+
+// tempFile is a path in the folder of the video inside the run folder, one
+// subfolder per step so the copies can keep the name of the video.
+func (p *videoPipeline) tempFile(job *videoJob, step, name string) (string, error) {
+	dir, err := p.videoDir(job, step)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// tempCopy is like tempFile, for a new version of source. Fails if the disk of
+// the run folder has no room for a file of the same size.
+func (p *videoPipeline) tempCopy(job *videoJob, source, step, name string) (string, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return "", err
+	}
+	if err := p.work.EnsureFree(info.Size(), "a cópia de "+job.video.FileName); err != nil {
+		return "", err
+	}
+	return p.tempFile(job, step, name)
+}
+
+// videoDir is a folder of the video inside the run folder, removed with removeTemp
+func (p *videoPipeline) videoDir(job *videoJob, step string) (string, error) {
+	dir, err := p.work.Sub(job.video.ID, step)
+	if err != nil {
+		return "", err
+	}
+	job.tempDir = filepath.Dir(dir)
+	return dir, nil
+}
+
+// splitVideo splits the source in parts of up to limit in outDir. A variable so
+// the tests don't need ffmpeg.
+var splitVideo = func(outDir, source string, limit int64) ([]string, error) {
+	ffmpeg := &processor.FFmpegSplitter{OutDir: outDir}
+	return ffmpeg.SplitVideo(source, limit)
+}
+
+// split splits the video in parts of up to maxSize. A video sent halfway by a
+// run with SIZE_LIMIT=compatible keeps that split: the number of parts is in the
+// keys of the state, with other parts the ones already sent would be sent again.
+// The cuts depend on the keyframes, so the parts are counted after splitting.
+func (p *videoPipeline) split(job *videoJob, fingerprint, source, outDir string) ([]string, error) {
+	parts, err := splitVideo(outDir, source, p.maxSize)
+	total, _ := state.UploadedParts(p.prog.Records(), fingerprint)
+	if err != nil || total == 0 || len(parts) == total {
+		return parts, err
+	}
+
+	if p.maxSize > domain.MaxFileSize {
+		removeParts(parts, source)
+		parts, err = splitVideo(outDir, source, domain.MaxFileSize)
+		if err != nil || len(parts) == total {
+			if err == nil {
+				fmt.Printf("   📏 %s foi enviado pela metade em %d partes, mantendo partes de até %s\n",
+					job.video.FileName, total, processor.FormatSize(domain.MaxFileSize))
+			}
+			return parts, err
+		}
+		removeParts(parts, source)
+		parts, err = splitVideo(outDir, source, p.maxSize)
+	}
+
+	log.Printf("   ⚠️ As partes de %s já enviadas usam outro SIZE_LIMIT, o vídeo será enviado de novo", job.video.FileName)
+	return parts, err
+}
+
+// legacyPartPath is the key of the part in the states of the versions keyed by
+// path, which split the video next to it as "Aula-part-0.mp4", "Aula-part-1.mp4"...
+func legacyPartPath(video string, i, total int) string {
+	if total == 1 {
+		return video
+	}
+	ext := filepath.Ext(video)
+	base := strings.TrimSuffix(filepath.Base(video), ext)
+	return filepath.Join(filepath.Dir(video), fmt.Sprintf("%s-part-%d%s", base, i, ext))
+}
+
+// removeParts deletes the parts of a split that won't be used, never the source
+func removeParts(parts []string, source string) {
+	for _, part := range parts {
+		if part != source {
+			os.Remove(part)
+		}
+	}
+}
+
+// ensureSplitSpace checks that the parts of the video fit in the run folder,
+// they are as big as the video together
+func (p *videoPipeline) ensureSplitSpace(job *videoJob, source string) error {
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if info.Size() <= p.maxSize {
+		return nil
+	}
+	return p.work.EnsureFree(info.Size(), "as partes de "+job.video.FileName)
+}
+
+// prepareUploaded creates the parts of a video sent in a previous run from the
+// state, without touching the file. The duration is all in the first part.
+func (p *videoPipeline) prepareUploaded(job *videoJob, fingerprint string, total int) {
+	duration, err := processor.VideoDuration(job.video.FilePath)
+	if err != nil {
+		log.Printf("   ⚠️ Sem duração para %s: %v", job.video.FileName, err)
+	}
+
+	for i := 0; i < total; i++ {
+		part := &partJob{
+			path:     job.video.FilePath,
+			key:      state.PartKey(fingerprint, i, total),
+			meta:     &processor.VideoMeta{},
+			skip:     true,
+			uploaded: make(chan struct{}),
+		}
+		if i == 0 {
+			part.meta.Duration = duration
+		}
+		close(part.uploaded)
+		job.parts = append(job.parts, part)
+	}
+	close(job.ready)
+}
+
+// send posts the uploaded parts following the queue order.
+func (p *videoPipeline) send(ctx context.Context, orderCh <-chan *videoJob, menu *index.Menu) (int, error) {
+	totalDuration := 0
+	var currentModule *domain.Module
+	var section *index.Section
+	sections := map[*domain.Module]*index.Section{}
+
+	// The temporary copies of the video where the upload stopped
+	var current *videoJob
+	defer func() {
+		if current != nil {
+			current.removeTemp()
+		}
+	}()
+
+	for job := range orderCh {
+		if job.module != currentModule {
+			currentModule = job.module
+			fmt.Printf("\n🔹 Processando Módulo: %s\n", currentModule.FullName())
+			section = moduleSection(menu, currentModule, sections)
+		}
+
+		select {
+		case <-job.ready:
+		case <-ctx.Done():
+			return totalDuration, ctx.Err()
+		}
+		current = job
+
+		if job.err != nil {
+			job.removeTemp()
+			log.Printf("❌ Erro ao preparar vídeo %s: %v", job.video.FileName, job.err)
+			p.emit(Event{Kind: EventError, Phase: PhaseVideos, Path: job.video.FilePath, Video: job.video, Err: job.err})
+			continue
+		}
+
+		// The index points to the first part of the video
+		firstMessageID := 0
+
+		for i, part := range job.parts {
+			// Sum the total duration to show
+			totalDuration += part.meta.Duration
+
+			select {
+			case <-part.uploaded:
+			case <-ctx.Done():
+				return totalDuration, ctx.Err()
+			}
+
+			event := Event{
+				Phase: PhaseVideos,
+				Path:  part.path,
+				Key:   part.key,
+				Part:  i + 1,
+				Parts: len(job.parts),
+				Video: job.video,
+			}
+
+			if part.skip {
+				fmt.Printf("⏩ Pulando (já enviado): %s\n", filepath.Base(part.path))
+				if rec, ok := p.prog.GetPart(part.key); ok {
+					if i == 0 {
+						firstMessageID = rec.MessageID
+					}
+					event.Message = sentFromRecord(rec)
+				}
+				cleanupPart(job.video, part)
+
+				event.Kind = EventPartSkipped
+				p.result.Skipped++
+				p.emit(event)
+				continue
+			}
+
+			if part.err != nil {
+				return totalDuration, part.err
+			}
+
+			caption := job.video.FormatCaption()
+			if len(job.parts) > 1 {
+				caption += fmt.Sprintf(" [Parte %d/%d]", i+1, len(job.parts))
+			}
+
+			fmt.Printf("📨 Enviando mensagem do vídeo %s... ", filepath.Base(part.path))
+			sent, err := p.bot.SendMedia(ctx, part.media, caption)
+			if err != nil {
+				return totalDuration, fmt.Errorf("erro envio vídeo: %w", err)
+			}
+			fmt.Println("✅ Vídeo enviado com sucesso")
+
+			// Mark the file as correctly uploaded in the state
+			if err := p.prog.MarkAsUploaded(part.key, newPartRecord(part.path, sent, caption)); err != nil {
+				log.Printf("⚠️ Erro ao salvar estado: %v", err)
+				p.emit(Event{Kind: EventError, Phase: PhaseVideos, Path: part.path, Key: part.key, Video: job.video, Err: err})
+			}
+			if i == 0 {
+				firstMessageID = sent.MessageID
+			}
+			cleanupPart(job.video, part)
+
+			event.Kind = EventPartUploaded
+			event.Message = sent
+			p.result.Uploaded++
+			p.emit(event)
+		}
+
+		job.removeTemp()
+
+		if job.replySubs {
+			if err := p.sendSubtitles(ctx, job, firstMessageID); err != nil {
+				return totalDuration, err
+			}
+		}
+
+		// Add the video to the index
+		section.Add(videoEntry(job.video, p.bot.MessageLink(firstMessageID)))
+	}
+
+	return totalDuration, nil
+}
+
+// removeTemp deletes the folder of the video in the run folder, if any
+func (job *videoJob) removeTemp() {
+	if job.tempDir != "" {
+		os.RemoveAll(job.tempDir)
+		job.tempDir = ""
+	}
+}
+
+// sendSubtitles posts the subtitles as documents replying to the first message of the video
+func (p *videoPipeline) sendSubtitles(ctx context.Context, job *videoJob, replyTo int) error {
+	video := job.video
+	for _, sub := range video.Subtitles {
+		event := Event{Phase: PhaseVideos, Path: sub.Path, Part: 1, Parts: 1, Video: video}
+
+		key := state.SubtitleKey(job.fingerprint, sub.Lang, sub.Path)
+		event.Key = key
+
+		if p.prog.IsDone(key, sub.Path) {
+			fmt.Printf("⏩ Pulando (já enviado): %s\n", filepath.Base(sub.Path))
+			if rec, ok := p.prog.GetPart(key); ok {
+				event.Message = sentFromRecord(rec)
+			}
+			event.Kind = EventPartSkipped
+			p.result.Skipped++
+			p.emit(event)
+			continue
+		}
+
+		// Without the message of the video the subtitle would be a loose document
+		if replyTo == 0 {
+			err := fmt.Errorf("mensagem do vídeo desconhecida, legenda não enviada: %s", filepath.Base(sub.Path))
+			log.Printf("   ⚠️ %v", err)
+			p.emit(Event{Kind: EventError, Phase: PhaseVideos, Path: sub.Path, Key: key, Video: video, Err: err})
+			continue
+		}
+
+		caption := fmt.Sprintf("#%s 💬 Legenda", video.ID)
+		if sub.Lang != "" {
+			caption += " (" + sub.Lang + ")"
+		}
+
+		sent, err := p.bot.ReplyWithDocument(ctx, replyTo, sub.Path, caption)
+		if err != nil {
+			return fmt.Errorf("erro envio legenda: %w", err)
+		}
+		if err := p.prog.MarkAsUploaded(key, newPartRecord(sub.Path, sent, caption)); err != nil {
+			log.Printf("⚠️ Erro ao salvar estado: %v", err)
+			p.emit(Event{Kind: EventError, Phase: PhaseVideos, Path: sub.Path, Key: key, Video: video, Err: err})
+		}
+
+		event.Kind = EventPartUploaded
+		event.Message = sent
+		p.result.Uploaded++
+		p.emit(event)
+	}
+	return nil
+}
+
+// cleanupPart removes the generated chunk and thumbnail. ALWAYS keeping the original
+func cleanupPart(video *domain.Video, part *partJob) {
+	if part.path != video.FilePath {
+		os.Remove(part.path)
+	}
+	if part.meta.ThumbPath != "" {
+		os.Remove(part.meta.ThumbPath)
+	}
+}
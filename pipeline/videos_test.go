@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/FolcloreX/GopherGram/internal/telegram/fake"
+)
+
+// Answers of the fake ffprobe, by the extension of the file
+const (
+	probeMP4 = `{"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "duration": "125.0", "tags": {"major_brand": "isom"}},
+		"streams": [{"codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080},
+			{"codec_type": "audio", "codec_name": "aac"}]}`
+	probeMKV = `{"format": {"format_name": "matroska,webm", "duration": "125.0"},
+		"streams": [{"codec_type": "video", "codec_name": "h264", "width": 1280, "height": 720},
+			{"codec_type": "audio", "codec_name": "aac"}]}`
+	probeHEVC = `{"format": {"format_name": "matroska,webm", "duration": "125.0"},
+		"streams": [{"codec_type": "video", "codec_name": "hevc", "width": 1280, "height": 720},
+			{"codec_type": "audio", "codec_name": "aac"}, {"codec_type": "audio", "codec_name": "ac3"}]}`
+	probeMP3 = `{"format": {"format_name": "mp3", "duration": "61.5", "tags": {"title": "Abertura", "artist": "Prof. Ana"}},
+		"streams": [{"codec_type": "audio", "codec_name": "mp3"},
+			{"codec_type": "video", "codec_name": "mjpeg", "disposition": {"attached_pic": 1}}]}`
+)
+
+// fakeFFmpeg puts on the PATH an ffprobe that answers the JSON of probes for the
+// files with that extension, and an ffmpeg that copies the input to the output
+// (the last argument). Returns the arguments of every ffmpeg call.
+func fakeFFmpeg(t *testing.T, probes map[string]string) func() []string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("ffmpeg falso em shell script")
+	}
+
+	bin := t.TempDir()
+	for ext, data := range probes {
+		if err := os.WriteFile(filepath.Join(bin, "probe."+ext+".json"), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log := filepath.Join(bin, "ffmpeg.log")
+
+	scripts := map[string]string{
+		"ffprobe": `for last; do :; done
+cat "` + bin + `/probe.${last##*.}.json" 2>/dev/null || exit 1
+`,
+		"ffmpeg": `echo "$*" >> "` + log + `"
+prev=""
+for arg; do
+	[ "$prev" = "-i" ] && in="$arg"
+	prev="$arg"
+done
+cp "$in" "$arg"
+`,
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return func() []string {
+		data, _ := os.ReadFile(log)
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
+
+func TestRunSendsAudioTagsAndCover(t *testing.T) {
+	fakeFFmpeg(t, map[string]string{"mp3": probeMP3, "mp4": probeMP4})
+	tr := newTestRun(t, "01 Podcast/01 abertura.mp3", "01 Podcast/02 aula.mp4")
+	result := tr.run(t)
+
+	chat := tr.srv.Chat(result.ChatID)
+	audio, video := chat.Messages[0], chat.Messages[1]
+	if audio.Title != "Abertura" || audio.Performer != "Prof. Ana" || audio.Duration != 61 {
+		t.Errorf("áudio = %q de %q, %ds, esperado as tags do arquivo", audio.Title, audio.Performer, audio.Duration)
+	}
+	if audio.Thumb != "01 abertura_thumb.jpg" {
+		t.Errorf("capa do áudio = %q", audio.Thumb)
+	}
+	if video.Thumb != "02 aula_thumb.jpg" || video.Duration != 125 || !video.Streaming {
+		t.Errorf("vídeo = thumb %q, %ds, streaming=%v", video.Thumb, video.Duration, video.Streaming)
+	}
+}
+
+func TestRunConvertsVideos(t *testing.T) {
+	cases := []struct {
+		name      string
+		probe     string
+		convert   string
+		ffmpeg    string // Argument of the conversion, empty if the video goes as it is
+		mimeType  string
+		streaming bool
+	}{
+		{"remux", probeMKV, ConvertRemux, "-c copy", "video/mp4", true},
+		{"hevc e ac3 não fazem remux", probeHEVC, ConvertRemux, "", "video/x-matroska", false},
+		{"transcode", probeHEVC, ConvertTranscode, "libx264", "video/mp4", true},
+		{"none", probeMKV, ConvertNone, "", "video/x-matroska", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := fakeFFmpeg(t, map[string]string{"mkv": tc.probe, "mp4": probeMP4})
+			tr := newTestRun(t, "01 Intro/01 a.mkv")
+			tr.opts.Convert = tc.convert
+			result := tr.run(t)
+
+			msg := tr.srv.Chat(result.ChatID).Messages[0]
+			if msg.MimeType != tc.mimeType || msg.Streaming != tc.streaming {
+				t.Errorf("enviado como %s streaming=%v, esperado %s streaming=%v", msg.MimeType, msg.Streaming, tc.mimeType, tc.streaming)
+			}
+
+			converted := false
+			for _, call := range calls() {
+				if strings.Contains(call, "-movflags +faststart") {
+					converted = true
+					if tc.ffmpeg == "" || !strings.Contains(call, tc.ffmpeg) {
+						t.Errorf("conversão = %q, esperado %q", call, tc.ffmpeg)
+					}
+				}
+			}
+			if converted != (tc.ffmpeg != "") {
+				t.Errorf("convertido = %v, esperado %v (ffmpeg: %v)", converted, tc.ffmpeg != "", calls())
+			}
+
+			// The original is never touched
+			if _, err := os.Stat(filepath.Join(tr.course.RootPath, "01 Intro", "01 a.mkv")); err != nil {
+				t.Error(err)
+			}
+			if msg.Kind != fake.KindVideo {
+				t.Errorf("tipo = %s", msg.Kind)
+			}
+		})
+	}
+}